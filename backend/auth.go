@@ -7,14 +7,17 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
-// generateToken creates a new random token
-func generateToken() (string, error) {
-	token := make([]byte, TokenSize)
+// generateToken creates a new random token of size bytes
+func generateToken(size int) (string, error) {
+	token := make([]byte, size)
 	_, err := rand.Read(token)
 	if err != nil {
 		return "", err
@@ -29,18 +32,54 @@ func hashToken(token string) string {
 	return hex.EncodeToString(sum[:])
 }
 
+// TokenPolicy defines how long authentication tokens remain valid
+type TokenPolicy struct {
+	TTL           time.Duration // absolute lifetime since creation
+	IdleTimeout   time.Duration // maximum inactivity since last use (sliding)
+	TouchInterval time.Duration // minimum delay between last_used_at updates
+}
+
+// ExpiresAt returns the moment the token expires if it is not used again
+func (p TokenPolicy) ExpiresAt(t db.UserToken) time.Time {
+	expiresAt := t.CreatedAt.Time.Add(p.TTL)
+	lastActivity := t.CreatedAt.Time
+	if t.LastUsedAt.Valid {
+		lastActivity = t.LastUsedAt.Time
+	}
+	if idle := lastActivity.Add(p.IdleTimeout); idle.Before(expiresAt) {
+		expiresAt = idle
+	}
+	return expiresAt
+}
+
+// Expired reports whether the token is past its absolute TTL or idle timeout
+func (p TokenPolicy) Expired(t db.UserToken, now time.Time) bool {
+	return !now.Before(p.ExpiresAt(t))
+}
+
+// needsTouch reports whether last_used_at is stale enough to be written again
+func (p TokenPolicy) needsTouch(t db.UserToken, now time.Time) bool {
+	return !t.LastUsedAt.Valid || now.Sub(t.LastUsedAt.Time) >= p.TouchInterval
+}
+
+// unauthorized writes a 401 response with a Bearer challenge describing the reason
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+message+`"`)
+	writeError(w, http.StatusUnauthorized, CodeInvalidToken, message)
+}
+
 // authMiddleware validates the authentication token and adds user ID to context
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "Authorization header required")
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, CodeInvalidToken, "Invalid authorization header format")
 			return
 		}
 
@@ -49,20 +88,80 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		token, err := s.db.GetTokenByHash(r.Context(), tokenHash)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				unauthorized(w, "Invalid token")
 				return
 			}
-			log.Printf("Error getting token: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "Error getting token", "error", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 			return
 		}
 
 		if token.Revoked {
-			http.Error(w, "Token revoked", http.StatusUnauthorized)
+			unauthorized(w, "Token revoked")
 			return
 		}
 
+		now := time.Now()
+		if s.tokens.Expired(token, now) {
+			unauthorized(w, "Token expired")
+			return
+		}
+
+		// Sliding session: record usage, throttled to limit writes
+		if s.tokens.needsTouch(token, now) {
+			if err := s.db.TouchToken(r.Context(), token.ID); err != nil {
+				slog.WarnContext(r.Context(), "Error updating token last use", "error", err)
+			}
+		}
+
+		setLogUser(r.Context(), token.UserID)
 		ctx := context.WithValue(r.Context(), UserIDKey, token.UserID)
+		ctx = context.WithValue(ctx, TokenIDKey, token.ID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// runTokenJanitor periodically purges revoked and expired tokens until ctx is done
+func (s *Server) runTokenJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeTokens(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeTokens deletes revoked tokens and tokens expired under the current policy,
+// then used or expired password reset tokens
+func (s *Server) purgeTokens(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.RequestTimeout)
+	defer cancel()
+
+	now := time.Now()
+	deleted, err := s.db.DeleteExpiredTokens(ctx, db.DeleteExpiredTokensParams{
+		CreatedBefore: now.Add(-s.tokens.TTL),
+		UsedBefore:    now.Add(-s.tokens.IdleTimeout),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error purging expired tokens", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Purged expired or revoked tokens", "count", deleted)
+	}
+
+	deleted, err = s.db.DeleteExpiredPasswordResetTokens(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error purging password reset tokens", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Purged used or expired password reset tokens", "count", deleted)
+	}
+}
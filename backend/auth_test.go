@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// Test scadenza token (TTL assoluto e inattività)
+func TestTokenPolicyExpired(t *testing.T) {
+	policy := TokenPolicy{TTL: 24 * time.Hour, IdleTimeout: time.Hour, TouchInterval: time.Minute}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		created  time.Time
+		lastUsed *time.Time
+		expired  bool
+	}{
+		{name: "Fresh token", created: now.Add(-time.Minute), expired: false},
+		{name: "Idle since creation", created: now.Add(-2 * time.Hour), expired: true},
+		{name: "Recently used", created: now.Add(-10 * time.Hour), lastUsed: timeRef(now.Add(-10 * time.Minute)), expired: false},
+		{name: "Idle since last use", created: now.Add(-10 * time.Hour), lastUsed: timeRef(now.Add(-90 * time.Minute)), expired: true},
+		{name: "Past absolute TTL", created: now.Add(-25 * time.Hour), lastUsed: timeRef(now.Add(-time.Minute)), expired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := db.UserToken{CreatedAt: sql.NullTime{Time: tt.created, Valid: true}}
+			if tt.lastUsed != nil {
+				token.LastUsedAt = sql.NullTime{Time: *tt.lastUsed, Valid: true}
+			}
+
+			if got := policy.Expired(token, now); got != tt.expired {
+				t.Errorf("Expected expired=%v, got %v", tt.expired, got)
+			}
+		})
+	}
+}
+
+// Test throttling degli aggiornamenti di last_used_at
+func TestTokenPolicyNeedsTouch(t *testing.T) {
+	policy := DefaultConfig().Tokens.TokenPolicy
+	now := time.Now()
+
+	if !policy.needsTouch(db.UserToken{}, now) {
+		t.Error("Expected never-used token to need touch")
+	}
+
+	recent := db.UserToken{LastUsedAt: sql.NullTime{Time: now.Add(-10 * time.Second), Valid: true}}
+	if policy.needsTouch(recent, now) {
+		t.Error("Expected recently used token not to need touch")
+	}
+
+	stale := db.UserToken{LastUsedAt: sql.NullTime{Time: now.Add(-2 * policy.TouchInterval), Valid: true}}
+	if !policy.needsTouch(stale, now) {
+		t.Error("Expected stale token to need touch")
+	}
+}
+
+// Test risposta 401 con motivo
+func TestUnauthorizedResponse(t *testing.T) {
+	rr := httptest.NewRecorder()
+	unauthorized(rr, "Token expired")
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Header().Get("WWW-Authenticate"), "Token expired") {
+		t.Errorf("Expected reason in WWW-Authenticate, got %q", rr.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func timeRef(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// Action identifies an operation on a conference that is subject to authorization
+type Action string
+
+// Conference actions checked by the permission layer
+const (
+	ActionUpdateConference Action = "conference:update"
+	ActionDeleteConference Action = "conference:delete"
+	ActionManageOrganizers Action = "conference:manage_organizers"
+	ActionViewAttendees    Action = "conference:view_attendees"
+)
+
+// AccessLevel is the privilege a user holds on a conference.
+// Levels are ordered: each level includes all the privileges of the lower ones.
+type AccessLevel int
+
+const (
+	// AccessMember is any authenticated user
+	AccessMember AccessLevel = iota
+	// AccessOrganizer is a co-organizer granted through conference_registrations.role
+	AccessOrganizer
+	// AccessOwner is the user who created the conference
+	AccessOwner
+	// AccessAdmin is a platform administrator
+	AccessAdmin
+)
+
+// String returns the name of the access level
+func (l AccessLevel) String() string {
+	switch l {
+	case AccessOrganizer:
+		return "organizer"
+	case AccessOwner:
+		return "owner"
+	case AccessAdmin:
+		return "admin"
+	default:
+		return "member"
+	}
+}
+
+// requiredAccess maps each action to the minimum access level allowed to perform it
+var requiredAccess = map[Action]AccessLevel{
+	ActionUpdateConference: AccessOrganizer,
+	ActionViewAttendees:    AccessOrganizer,
+	ActionManageOrganizers: AccessOwner,
+	ActionDeleteConference: AccessOwner,
+}
+
+// Can reports whether the access level is sufficient for the action.
+// Unknown actions are always denied.
+func (l AccessLevel) Can(action Action) bool {
+	required, ok := requiredAccess[action]
+	if !ok {
+		return false
+	}
+	return l >= required
+}
+
+// accessLevelFromRow derives the strongest access level from the database flags
+func accessLevelFromRow(row db.GetConferenceAccessRow) AccessLevel {
+	switch {
+	case row.IsAdmin:
+		return AccessAdmin
+	case row.IsOwner:
+		return AccessOwner
+	case row.IsOrganizer:
+		return AccessOrganizer
+	default:
+		return AccessMember
+	}
+}
+
+// conferenceAccess returns the access level of a user on a conference.
+// It returns sql.ErrNoRows if either the user or the conference does not exist.
+func (s *Server) conferenceAccess(ctx context.Context, userID, conferenceID uuid.UUID) (AccessLevel, error) {
+	row, err := s.db.GetConferenceAccess(ctx, db.GetConferenceAccessParams{
+		UserID:       userID,
+		ConferenceID: conferenceID,
+	})
+	if err != nil {
+		return AccessMember, err
+	}
+	return accessLevelFromRow(row), nil
+}
+
+// authorizeConference checks that the user may perform the action on the conference.
+// On failure it writes the error response and returns false.
+func (s *Server) authorizeConference(ctx context.Context, w http.ResponseWriter, userID, conferenceID uuid.UUID, action Action) bool {
+	level, err := s.conferenceAccess(ctx, userID, conferenceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
+			return false
+		}
+		slog.ErrorContext(ctx, "Error checking conference permissions", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return false
+	}
+
+	if !level.Can(action) {
+		writeError(w, http.StatusForbidden, CodeForbidden, "User not authorized to perform this action")
+		return false
+	}
+	return true
+}
+
+// verifiedMiddleware rejects users who have not verified their email address.
+// It must run after authMiddleware.
+func (s *Server) verifiedMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.contextUser(w, r)
+		if !ok {
+			return
+		}
+
+		if !user.EmailVerifiedAt.Valid {
+			writeError(w, http.StatusForbidden, CodeEmailNotVerified, "Email address not verified")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminMiddleware only lets platform administrators through.
+// It must run after authMiddleware.
+func (s *Server) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.contextUser(w, r)
+		if !ok {
+			return
+		}
+
+		if !user.IsAdmin {
+			writeError(w, http.StatusForbidden, CodeAdminRequired, "Administrator privileges required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// contextUserID returns the ID of the user authenticated by authMiddleware,
+// writing a 401 response if the request is not authenticated
+func contextUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "User not found in context")
+	}
+	return userID, ok
+}
+
+// contextTokenID returns the ID of the token used to authenticate the request,
+// writing a 401 response if the request is not authenticated
+func contextTokenID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tokenID, ok := r.Context().Value(TokenIDKey).(uuid.UUID)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "Token not found in context")
+	}
+	return tokenID, ok
+}
+
+// contextUser loads the user authenticated by authMiddleware, writing the
+// error response if it cannot
+func (s *Server) contextUser(w http.ResponseWriter, r *http.Request) (db.User, bool) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return db.User{}, false
+	}
+
+	user, err := s.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "User not found")
+			return db.User{}, false
+		}
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return db.User{}, false
+	}
+	return user, true
+}
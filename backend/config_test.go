@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envMap restituisce una funzione getenv basata su una mappa
+func envMap(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+// Test configurazione di default valida
+func TestDefaultConfigIsValid(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected default config to be valid, got %v", err)
+	}
+	// Di default solo il frontend locale e nessuna metrica esposta
+	if strings.Join(cfg.CORS.AllowedOrigins, ",") != "http://localhost:5173" {
+		t.Errorf("Expected only the dev origin by default, got %v", cfg.CORS.AllowedOrigins)
+	}
+	if cfg.Metrics.Enabled {
+		t.Error("Expected metrics disabled by default")
+	}
+}
+
+// Test precedenza: default < file < variabili d'ambiente < flag
+func TestLoadConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := `port: "9000"
+log_level: debug
+database:
+  url: postgres://file/db
+  max_open_conns: 10
+  max_idle_conns: 5
+tokens:
+  ttl: 48h
+  size: 48
+cors:
+  allowed_origins:
+    - https://file.example
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	env := envMap(map[string]string{
+		"CONFIG_FILE":          file,
+		"PORT":                 "9100",
+		"DB_MAX_OPEN_CONNS":    "20",
+		"CORS_ALLOWED_ORIGINS": "https://a.example, https://*.b.example",
+	})
+	cfg, args, err := LoadConfig([]string{"-port", "9200", "migrate", "up"}, env)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Port != "9200" {
+		t.Errorf("Expected port from flag, got %q", cfg.Port)
+	}
+	if cfg.Database.MaxOpenConns != 20 {
+		t.Errorf("Expected max open conns from env, got %d", cfg.Database.MaxOpenConns)
+	}
+	if cfg.Database.URL != "postgres://file/db" || cfg.Database.MaxIdleConns != 5 || cfg.LogLevel != "debug" {
+		t.Errorf("Expected values from file, got %+v", cfg)
+	}
+	if cfg.Tokens.TTL != 48*time.Hour || cfg.Tokens.Size != 48 {
+		t.Errorf("Expected token settings from file, got %+v", cfg.Tokens)
+	}
+	if cfg.Tokens.IdleTimeout != DefaultConfig().Tokens.IdleTimeout {
+		t.Errorf("Expected default idle timeout, got %s", cfg.Tokens.IdleTimeout)
+	}
+	if strings.Join(cfg.CORS.AllowedOrigins, ",") != "https://a.example,https://*.b.example" {
+		t.Errorf("Expected CORS origins from env, got %v", cfg.CORS.AllowedOrigins)
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Errorf("Expected remaining args 'migrate up', got %v", args)
+	}
+}
+
+// Test errori di caricamento e validazione
+func TestLoadConfigErrors(t *testing.T) {
+	unknownKey := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(unknownKey, []byte("prot: 8080\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"Invalid port", []string{"-port", "99999"}, nil, "port"},
+		{"Invalid duration in env", nil, map[string]string{"TOKEN_TTL": "forever"}, "TOKEN_TTL"},
+		{"Invalid log level", []string{"-log-level", "verbose"}, nil, "log level"},
+		{"Invalid log format", nil, map[string]string{"LOG_FORMAT": "xml"}, "log format"},
+		{"Invalid CORS origin", nil, map[string]string{"CORS_ALLOWED_ORIGINS": "example.com"}, "CORS origin"},
+		{"Wildcard in the middle of a CORS origin", nil, map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.*.example.com"}, "CORS origin"},
+		{"CORS credentials with any origin", []string{"-cors-origins", "*", "-cors-allow-credentials"}, nil, "credentials"},
+		{"Idle above open conns", nil, map[string]string{"DB_MAX_OPEN_CONNS": "2", "DB_MAX_IDLE_CONNS": "5"}, "idle connections"},
+		{"Write timeout below request timeout", nil, map[string]string{"REQUEST_TIMEOUT": "30s"}, "write timeout"},
+		{"Token too short", nil, map[string]string{"TOKEN_SIZE": "8"}, "token size"},
+		{"Invalid mail sender", nil, map[string]string{"MAIL_FROM": "noreply"}, "mail sender"},
+		{"Account token secret too short", nil, map[string]string{"ACCOUNT_TOKEN_SECRET": "secret"}, "token secret"},
+		{"SMTP without account token secret", nil, map[string]string{"SMTP_HOST": "smtp.example.com"}, "token secret is required"},
+		{"Relative verify URL", nil, map[string]string{"EMAIL_VERIFY_URL": "/verifica-email"}, "verify URL"},
+		{"Non-positive reset TTL", nil, map[string]string{"PASSWORD_RESET_TTL": "0s"}, "reset TTL"},
+		{"Metrics without token", []string{"-metrics"}, nil, "metrics token"},
+		{"Unknown flag", []string{"-verbose"}, nil, "verbose"},
+		{"Missing config file", []string{"-config", "/does/not/exist.yaml"}, nil, "config file"},
+		{"Unknown key in config file", []string{"-config", unknownKey}, nil, "prot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadConfig(tt.args, envMap(tt.env))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -3,35 +3,263 @@ package db
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword genera un hash SHA-256 combinato con un salt casuale.
-// Ritorna una stringa nel formato salt:hash
-func HashPassword(password string) (string, error) {
-	salt := make([]byte, 16)
-	_, err := rand.Read(salt)
+// Algoritmi di hashing supportati
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// ErrUnknownHashFormat viene restituito quando l'hash memorizzato non
+// corrisponde a nessuno dei formati riconosciuti.
+var ErrUnknownHashFormat = errors.New("formato hash password sconosciuto")
+
+// PasswordHasher definisce un algoritmo di hashing delle password.
+// Gli hash prodotti sono auto-descrittivi (algoritmo e parametri inclusi),
+// quindi è possibile cambiare algoritmo senza invalidare quelli esistenti.
+type PasswordHasher interface {
+	// Hash genera l'hash codificato della password
+	Hash(password string) (string, error)
+	// NeedsRehash indica se l'hash memorizzato va rigenerato con questo hasher
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idHasher implementa PasswordHasher con argon2id.
+// Formato: $argon2id$v=19$m=<memoria>,t=<iterazioni>,p=<parallelismo>$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // memoria in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher crea un Argon2idHasher con i parametri raccomandati da OWASP
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Hash genera l'hash argon2id della password con un salt casuale
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Memory,
+		h.Iterations,
+		h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// NeedsRehash restituisce true se l'hash non è argon2id o usa parametri diversi
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory ||
+		params.Iterations != h.Iterations ||
+		params.Parallelism != h.Parallelism ||
+		uint32(len(salt)) != h.SaltLength ||
+		uint32(len(key)) != h.KeyLength
+}
+
+// BcryptHasher implementa PasswordHasher con bcrypt.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher crea un BcryptHasher con il costo di default
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{Cost: bcrypt.DefaultCost}
+}
+
+// Hash genera l'hash bcrypt della password
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	if err != nil {
 		return "", err
 	}
+	return string(hash), nil
+}
 
-	hash := sha256.Sum256(append([]byte(password), salt...))
-	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(hash[:]), nil
+// NeedsRehash restituisce true se l'hash non è bcrypt o usa un costo diverso
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// NewPasswordHasher restituisce l'hasher corrispondente al nome dell'algoritmo
+func NewPasswordHasher(algorithm string) (PasswordHasher, error) {
+	switch strings.ToLower(algorithm) {
+	case "", AlgorithmArgon2id:
+		return NewArgon2idHasher(), nil
+	case AlgorithmBcrypt:
+		return NewBcryptHasher(), nil
+	default:
+		return nil, fmt.Errorf("algoritmo di hashing non supportato: %s", algorithm)
+	}
+}
+
+// passwordHasher è l'hasher usato per i nuovi hash
+var passwordHasher PasswordHasher = NewArgon2idHasher()
+
+// SetPasswordHasher imposta l'hasher usato da HashPassword.
+// Va chiamata all'avvio, prima di servire richieste.
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHasher = h
+}
+
+// HashPassword genera l'hash della password con l'hasher configurato.
+func HashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
 }
 
 // CheckPasswordHash verifica se una password corrisponde all'hash memorizzato.
+// Accetta tutti i formati supportati, incluso il formato legacy salt:sha256.
 func CheckPasswordHash(password, stored string) bool {
-	parts := strings.Split(stored, ":")
-	if len(parts) != 2 {
-		return false
+	ok, err := verifyPassword(password, stored)
+	return err == nil && ok
+}
+
+// PasswordNeedsRehash indica se l'hash memorizzato è in un formato obsoleto
+// o con parametri diversi da quelli dell'hasher configurato.
+func PasswordNeedsRehash(stored string) bool {
+	return passwordHasher.NeedsRehash(stored)
+}
+
+// dummyHash è un hash dell'hasher configurato, generato al primo uso
+var dummyHash struct {
+	sync.Mutex
+	hasher  PasswordHasher
+	encoded string
+}
+
+// CheckDummyPassword verifica la password contro un hash fittizio dell'hasher
+// configurato e restituisce sempre false. Va usata quando l'utente non esiste,
+// così la risposta richiede lo stesso tempo di una password sbagliata.
+func CheckDummyPassword(password string) bool {
+	dummyHash.Lock()
+	if dummyHash.hasher != passwordHasher {
+		encoded, err := passwordHasher.Hash("conferenze.tech dummy password")
+		if err == nil {
+			dummyHash.hasher, dummyHash.encoded = passwordHasher, encoded
+		}
+	}
+	encoded := dummyHash.encoded
+	dummyHash.Unlock()
+
+	CheckPasswordHash(password, encoded)
+	return false
+}
+
+// verifyPassword riconosce il formato dell'hash e verifica la password
+func verifyPassword(password, stored string) (bool, error) {
+	switch {
+	case strings.HasPrefix(stored, "$argon2id$"):
+		return verifyArgon2id(password, stored)
+	case isBcrypt(stored):
+		err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.Count(stored, ":") == 1:
+		return verifyLegacySHA256(password, stored)
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+// isBcrypt riconosce i prefissi degli hash bcrypt
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2id estrae parametri, salt e chiave da un hash argon2id
+func decodeArgon2id(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("versione argon2 non supportata: %d", version)
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// verifyArgon2id ricalcola la chiave con i parametri memorizzati e la confronta
+func verifyArgon2id(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// verifyLegacySHA256 verifica gli hash nel vecchio formato salt:sha256.
+// Mantenuto solo per migrare gli account esistenti al primo login.
+func verifyLegacySHA256(password, stored string) (bool, error) {
+	parts := strings.Split(stored, ":")
 
 	salt, err := hex.DecodeString(parts[0])
 	if err != nil {
-		return false
+		return false, err
 	}
 
 	hash := sha256.Sum256(append([]byte(password), salt...))
-	return parts[1] == hex.EncodeToString(hash[:])
+	expected := hex.EncodeToString(hash[:])
+	return subtle.ConstantTimeCompare([]byte(parts[1]), []byte(expected)) == 1, nil
 }
@@ -0,0 +1,314 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID è la chiave dell'advisory lock che serializza le migrazioni
+// tra più istanze del backend avviate in contemporanea
+const migrationLockID int64 = 7_361_029_418
+
+// migrationName riconosce i file nel formato 0001_descrizione.up.sql / .down.sql
+var migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration è una migrazione numerata con lo script di applicazione e quello di rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus indica se una migrazione è stata applicata e quando
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applica le migrazioni embedded nel binario
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator crea un Migrator con le migrazioni della directory migrations/
+func NewMigrator(sqlDB *sql.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, migrations: migrations}, nil
+}
+
+// LoadMigrations legge le migrazioni da dir, ordinate per versione.
+// Ogni versione deve avere sia il file up che il file down.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("nome migrazione non valido: %s", entry.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("versione migrazione non valida: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("versione %d usata da due migrazioni: %s e %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migrazione %04d_%s: mancano i file up o down", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrations restituisce tutte le migrazioni conosciute, ordinate per versione
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applica tutte le migrazioni non ancora eseguite e restituisce quelle applicate
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.adoptLegacySchema(ctx, conn, done); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, migration, migration.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// adoptLegacySchema registra la baseline come applicata sui database creati con
+// il vecchio schema.sql (tabella users presente, nessuna migrazione registrata),
+// così l'avvio con AUTO_MIGRATE non fallisce con "relation already exists".
+// Le migrazioni successive sono idempotenti e completano lo schema.
+func (m *Migrator) adoptLegacySchema(ctx context.Context, conn *sql.Conn, done map[int64]time.Time) error {
+	if len(done) > 0 || len(m.migrations) == 0 || m.migrations[0].Version != 1 {
+		return nil
+	}
+	var legacy bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('users') IS NOT NULL`).Scan(&legacy); err != nil {
+		return err
+	}
+	if !legacy {
+		return nil
+	}
+	baseline := m.migrations[0]
+	if _, err := conn.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, baseline.Version, baseline.Name); err != nil {
+		return err
+	}
+	done[baseline.Version] = time.Now()
+	return nil
+}
+
+// Down annulla le ultime steps migrazioni applicate e restituisce quelle annullate
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, migration, migration.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Baseline registra come applicate le migrazioni fino a version senza eseguirle.
+// Up registra da solo la 0001 sui database creati con il vecchio schema.sql;
+// Baseline serve quando lo schema esistente include anche migrazioni successive.
+func (m *Migrator) Baseline(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING`,
+				migration.Version, migration.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status restituisce lo stato di tutte le migrazioni
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var status []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		status = make([]MigrationStatus, len(m.migrations))
+		for i, migration := range m.migrations {
+			status[i] = MigrationStatus{Migration: migration}
+			if appliedAt, ok := done[migration.Version]; ok {
+				status[i].AppliedAt = &appliedAt
+			}
+		}
+		return nil
+	})
+	return status, err
+}
+
+// withLock esegue fn su una connessione dedicata che detiene l'advisory lock.
+// La tabella schema_migrations viene creata se non esiste.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("impossibile acquisire il lock delle migrazioni: %w", err)
+	}
+	defer func() {
+		// Il lock viene rilasciato comunque alla chiusura della sessione
+		if _, unlockErr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+)`); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// Pending restituisce le migrazioni non ancora applicate.
+// Non acquisisce il lock né crea schema_migrations, quindi è adatto ai controlli
+// di readiness: se la tabella non esiste restituisce l'errore del database.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	done, err := appliedVersions(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// queryer è implementato sia da *sql.DB che da *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// appliedVersions legge le versioni già applicate con la data di applicazione
+func appliedVersions(ctx context.Context, conn queryer) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+// runMigration esegue lo script e aggiorna schema_migrations nella stessa transazione
+func runMigration(ctx context.Context, conn *sql.Conn, migration Migration, script, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Dopo il commit il rollback restituisce sql.ErrTxDone, che viene ignorato
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migrazione %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
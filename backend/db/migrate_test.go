@@ -0,0 +1,79 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_rides.up.sql":   {Data: []byte("CREATE TABLE rides ();")},
+		"m/0002_add_rides.down.sql": {Data: []byte("DROP TABLE rides;")},
+		"m/0001_baseline.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"m/0001_baseline.down.sql":  {Data: []byte("DROP TABLE users;")},
+	}
+
+	migrations, err := LoadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "baseline" || migrations[1].Version != 2 {
+		t.Errorf("Unexpected order: %+v", migrations)
+	}
+	if migrations[1].Down != "DROP TABLE rides;" {
+		t.Errorf("Unexpected down script: %q", migrations[1].Down)
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"Missing down": {
+			"m/0001_baseline.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"Invalid name": {
+			"m/baseline.sql": {Data: []byte("SELECT 1;")},
+		},
+		"Duplicate version": {
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"m/0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"m/0001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"m/0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(fsys, "m"); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+// Le migrazioni embedded devono essere valide e partire dalla baseline
+func TestEmbeddedMigrations(t *testing.T) {
+	migrator, err := NewMigrator(nil)
+	if err != nil {
+		t.Fatalf("NewMigrator failed: %v", err)
+	}
+	migrations := migrator.Migrations()
+	if len(migrations) == 0 || migrations[0].Version != 1 || migrations[0].Name != "baseline" {
+		t.Fatalf("Expected baseline as first migration, got %+v", migrations)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("Expected contiguous versions, got %d at position %d", m.Version, i)
+		}
+	}
+
+	// La baseline è il vecchio schema.sql: le modifiche successive hanno migrazioni proprie
+	for _, later := range []string{"is_admin", "capacity", "ride_offers", "idx_conferences_search"} {
+		if strings.Contains(migrations[0].Up, later) {
+			t.Errorf("Baseline must not include %s", later)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+-- Reverts the baseline schema: drops every table and all of its data
+
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS conference_registrations;
+DROP TABLE IF EXISTS conferences;
+DROP TABLE IF EXISTS users;
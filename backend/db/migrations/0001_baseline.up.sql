@@ -1,5 +1,5 @@
--- PostgreSQL schema for conferenze.tech
--- Run: sqlc generate
+-- Baseline schema for conferenze.tech: the original schema.sql, unchanged
+-- Schema changes go in new numbered migrations; run sqlc generate afterwards
 
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
 
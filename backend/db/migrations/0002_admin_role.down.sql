@@ -0,0 +1,3 @@
+-- Reverts platform administrators
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,5 @@
+-- Platform administrators
+-- Like the migrations up to 0006, it is idempotent: databases created from a
+-- later version of the old schema.sql already have this change
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- Reverts conference capacity
+
+DROP INDEX IF EXISTS idx_registrations_waitlist;
+ALTER TABLE conferences DROP COLUMN IF EXISTS capacity;
@@ -0,0 +1,4 @@
+-- Conference capacity: registrations beyond it go to the waitlist
+
+ALTER TABLE conferences ADD COLUMN IF NOT EXISTS capacity INTEGER CHECK (capacity > 0);
+CREATE INDEX IF NOT EXISTS idx_registrations_waitlist ON conference_registrations(conference_id, registered_at) WHERE status = 'waitlist';
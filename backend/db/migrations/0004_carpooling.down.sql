@@ -0,0 +1,4 @@
+-- Reverts carpooling: drops offers and requests
+
+DROP TABLE IF EXISTS ride_requests;
+DROP TABLE IF EXISTS ride_offers;
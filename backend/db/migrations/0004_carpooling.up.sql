@@ -0,0 +1,34 @@
+-- Carpooling: ride offers by drivers and ride requests by passengers
+
+CREATE TABLE IF NOT EXISTS ride_offers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    conference_id UUID NOT NULL REFERENCES conferences(id) ON DELETE CASCADE,
+    driver_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seats INTEGER NOT NULL CHECK (seats > 0),
+    seats_taken INTEGER NOT NULL DEFAULT 0 CHECK (seats_taken >= 0 AND seats_taken <= seats),
+    departure_city VARCHAR(100) NOT NULL,
+    departure_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    departure_latitude DOUBLE PRECISION,
+    departure_longitude DOUBLE PRECISION,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(conference_id, driver_id)
+);
+
+CREATE TABLE IF NOT EXISTS ride_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    conference_id UUID NOT NULL REFERENCES conferences(id) ON DELETE CASCADE,
+    passenger_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offer_id UUID REFERENCES ride_offers(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'pending', 'accepted', 'declined', 'cancelled')),
+    pickup_city VARCHAR(100),
+    pickup_latitude DOUBLE PRECISION,
+    pickup_longitude DOUBLE PRECISION,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(conference_id, passenger_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_offers_conference ON ride_offers(conference_id);
+CREATE INDEX IF NOT EXISTS idx_ride_requests_offer ON ride_requests(offer_id);
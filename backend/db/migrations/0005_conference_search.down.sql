@@ -0,0 +1,5 @@
+-- Reverts the conference listing indexes
+
+DROP INDEX IF EXISTS idx_conferences_search;
+DROP INDEX IF EXISTS idx_conferences_date;
+CREATE INDEX idx_conferences_date ON conferences(date);
@@ -0,0 +1,5 @@
+-- Conference listing: keyset pagination on (date, id) and full-text search
+
+DROP INDEX IF EXISTS idx_conferences_date;
+CREATE INDEX idx_conferences_date ON conferences(date, id);
+CREATE INDEX IF NOT EXISTS idx_conferences_search ON conferences USING GIN (to_tsvector('simple', title || ' ' || location));
@@ -0,0 +1,3 @@
+-- Reverts the coordinates index
+
+DROP INDEX IF EXISTS idx_conferences_coordinates;
@@ -0,0 +1,3 @@
+-- Nearby search: bounding-box prefilter on the conference coordinates
+
+CREATE INDEX IF NOT EXISTS idx_conferences_coordinates ON conferences(latitude, longitude) WHERE latitude IS NOT NULL AND longitude IS NOT NULL;
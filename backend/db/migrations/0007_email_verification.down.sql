@@ -0,0 +1,3 @@
+-- Reverts email verification: drops the verification timestamps
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,7 @@
+-- Email verification: new accounts must confirm their address before creating
+-- conferences or registering to them
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Accounts created before verification was introduced are trusted as they are
+UPDATE users SET email_verified_at = COALESCE(created_at, NOW());
@@ -0,0 +1,3 @@
+-- Reverts password reset: drops the reset tokens
+
+DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,3 @@
+-- Reverts ride declines: drops the declines table
+
+DROP TABLE IF EXISTS ride_declines;
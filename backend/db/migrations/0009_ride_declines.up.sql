@@ -0,0 +1,10 @@
+-- Ride declines: the offers whose driver declined a passenger, so the
+-- passenger cannot ask to join the same offer again
+
+CREATE TABLE ride_declines (
+    offer_id UUID NOT NULL REFERENCES ride_offers(id) ON DELETE CASCADE,
+    passenger_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    declined_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (offer_id, passenger_id)
+);
+CREATE INDEX idx_ride_declines_passenger_id ON ride_declines(passenger_id);
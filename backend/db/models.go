@@ -22,6 +22,7 @@ type Conference struct {
 	CreatedBy uuid.UUID
 	CreatedAt sql.NullTime
 	UpdatedAt sql.NullTime
+	Capacity  sql.NullInt32
 }
 
 type ConferenceRegistration struct {
@@ -37,17 +38,62 @@ type ConferenceRegistration struct {
 	CancelledAt  sql.NullTime
 }
 
-type User struct {
+type PasswordResetToken struct {
 	ID        uuid.UUID
-	Email     string
-	Password  string
-	Name      string
-	Nickname  sql.NullString
-	City      sql.NullString
-	AvatarUrl sql.NullString
-	Bio       sql.NullString
+	UserID    uuid.UUID
+	TokenHash string
 	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type RideDecline struct {
+	OfferID     uuid.UUID
+	PassengerID uuid.UUID
+	DeclinedAt  sql.NullTime
+}
+
+type RideOffer struct {
+	ID                 uuid.UUID
+	ConferenceID       uuid.UUID
+	DriverID           uuid.UUID
+	Seats              int32
+	SeatsTaken         int32
+	DepartureCity      string
+	DepartureTime      time.Time
+	DepartureLatitude  sql.NullFloat64
+	DepartureLongitude sql.NullFloat64
+	Notes              sql.NullString
+	CreatedAt          sql.NullTime
+}
+
+type RideRequest struct {
+	ID              uuid.UUID
+	ConferenceID    uuid.UUID
+	PassengerID     uuid.UUID
+	OfferID         uuid.NullUUID
+	Status          string
+	PickupCity      sql.NullString
+	PickupLatitude  sql.NullFloat64
+	PickupLongitude sql.NullFloat64
+	Notes           sql.NullString
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+}
+
+type User struct {
+	ID              uuid.UUID
+	Email           string
+	Password        string
+	Name            string
+	Nickname        sql.NullString
+	City            sql.NullString
+	AvatarUrl       sql.NullString
+	Bio             sql.NullString
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	IsAdmin         bool
+	EmailVerifiedAt sql.NullTime
 }
 
 type UserToken struct {
@@ -6,42 +6,86 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
 	CancelRegistration(ctx context.Context, id uuid.UUID) (ConferenceRegistration, error)
+	CountConfirmedRegistrations(ctx context.Context, conferenceID uuid.UUID) (int64, error)
 	CreateConference(ctx context.Context, arg CreateConferenceParams) (Conference, error)
-	// Token management queries (user_tokens table must be present in schema.sql)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	// An existing offer by the same driver makes the insert return no rows
+	CreateRideOffer(ctx context.Context, arg CreateRideOfferParams) (RideOffer, error)
+	// A cancelled request is reopened in place; an active one makes the insert return no rows
+	CreateRideRequest(ctx context.Context, arg CreateRideRequestParams) (RideRequest, error)
+	// Token management queries (user_tokens table is created by the baseline migration)
 	CreateToken(ctx context.Context, arg CreateTokenParams) (UserToken, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAllConferences(ctx context.Context) error
 	DeleteAllRegistrations(ctx context.Context) error
 	DeleteAllUsers(ctx context.Context) error
 	DeleteConference(ctx context.Context, id uuid.UUID) error
+	DeleteExpiredPasswordResetTokens(ctx context.Context) (int64, error)
+	DeleteExpiredTokens(ctx context.Context, arg DeleteExpiredTokensParams) (int64, error)
 	DeleteRegistration(ctx context.Context, arg DeleteRegistrationParams) error
+	DeleteRideOffer(ctx context.Context, id uuid.UUID) error
 	DeleteToken(ctx context.Context, id uuid.UUID) error
+	GetConferenceAccess(ctx context.Context, arg GetConferenceAccessParams) (GetConferenceAccessRow, error)
 	GetConferenceByID(ctx context.Context, id uuid.UUID) (Conference, error)
 	GetConferenceStats(ctx context.Context, id uuid.UUID) (GetConferenceStatsRow, error)
+	// Read-only check of an unused token, done before the password is hashed
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
 	GetRegistration(ctx context.Context, arg GetRegistrationParams) (ConferenceRegistration, error)
-	GetRegistrationsByConference(ctx context.Context, conferenceID uuid.UUID) ([]GetRegistrationsByConferenceRow, error)
+	GetRegistrationsByConference(ctx context.Context, arg GetRegistrationsByConferenceParams) ([]GetRegistrationsByConferenceRow, error)
 	GetRegistrationsByUser(ctx context.Context, userID uuid.UUID) ([]GetRegistrationsByUserRow, error)
+	GetRideOffer(ctx context.Context, id uuid.UUID) (RideOffer, error)
+	GetRideOfferByDriver(ctx context.Context, arg GetRideOfferByDriverParams) (RideOffer, error)
+	GetRideRequestByPassenger(ctx context.Context, arg GetRideRequestByPassengerParams) (RideRequest, error)
 	GetTokenByHash(ctx context.Context, tokenHash string) (UserToken, error)
 	GetTokensByUser(ctx context.Context, userID uuid.UUID) ([]UserToken, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
-	ListConferences(ctx context.Context) ([]Conference, error)
-	ListConferencesByLocation(ctx context.Context, location string) ([]Conference, error)
-	ListUpcomingConferences(ctx context.Context) ([]Conference, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID) error
+	IsRideDeclined(ctx context.Context, arg IsRideDeclinedParams) (bool, error)
+	// Filters are optional; the cursor is the (date, id) of the last row of the previous page
+	ListConferences(ctx context.Context, arg ListConferencesParams) ([]Conference, error)
+	// The bounding box prefilter uses idx_conferences_coordinates; the haversine distance is exact
+	ListConferencesNearby(ctx context.Context, arg ListConferencesNearbyParams) ([]ListConferencesNearbyRow, error)
+	ListDeclinedRideOffers(ctx context.Context, arg ListDeclinedRideOffersParams) ([]uuid.UUID, error)
+	ListRideOffersByConference(ctx context.Context, conferenceID uuid.UUID) ([]ListRideOffersByConferenceRow, error)
+	ListRideRequestsByOffer(ctx context.Context, offerID uuid.NullUUID) ([]ListRideRequestsByOfferRow, error)
+	ListUpcomingConferenceMetrics(ctx context.Context) ([]ListUpcomingConferenceMetricsRow, error)
 	ListUsersNeedingRide(ctx context.Context, conferenceID uuid.UUID) ([]ListUsersNeedingRideRow, error)
 	ListUsersOfferingRide(ctx context.Context, conferenceID uuid.UUID) ([]ListUsersOfferingRideRow, error)
+	LockConferenceCapacity(ctx context.Context, id uuid.UUID) (sql.NullInt32, error)
+	LockRideRequest(ctx context.Context, id uuid.UUID) (RideRequest, error)
+	PromoteNextWaitlisted(ctx context.Context, conferenceID uuid.UUID) (ConferenceRegistration, error)
+	ReactivateRegistration(ctx context.Context, arg ReactivateRegistrationParams) (ConferenceRegistration, error)
+	RecordRideDecline(ctx context.Context, arg RecordRideDeclineParams) error
 	RegisterUserToConference(ctx context.Context, arg RegisterUserToConferenceParams) (ConferenceRegistration, error)
+	ReleaseRideSeat(ctx context.Context, id uuid.UUID) error
+	RemoveConferenceOrganizer(ctx context.Context, arg RemoveConferenceOrganizerParams) (ConferenceRegistration, error)
+	ReopenRideRequestsByOffer(ctx context.Context, offerID uuid.NullUUID) ([]uuid.UUID, error)
+	ReserveRideSeat(ctx context.Context, id uuid.UUID) (RideOffer, error)
+	RevokeAllUserTokens(ctx context.Context, userID uuid.UUID) (int64, error)
+	RevokeOtherUserTokens(ctx context.Context, arg RevokeOtherUserTokensParams) (int64, error)
 	RevokeToken(ctx context.Context, id uuid.UUID) (UserToken, error)
+	RevokeUserToken(ctx context.Context, arg RevokeUserTokenParams) (UserToken, error)
+	SetRegistrationRideFlags(ctx context.Context, arg SetRegistrationRideFlagsParams) error
+	SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error)
+	TouchToken(ctx context.Context, id uuid.UUID) error
 	UpdateConference(ctx context.Context, arg UpdateConferenceParams) (Conference, error)
 	UpdateRegistrationStatus(ctx context.Context, arg UpdateRegistrationStatusParams) (ConferenceRegistration, error)
+	UpdateRideRequestStatus(ctx context.Context, arg UpdateRideRequestStatusParams) (RideRequest, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	// Active registrations keep their status; new and cancelled ones take the status computed from the capacity
+	UpsertConferenceOrganizer(ctx context.Context, arg UpsertConferenceOrganizerParams) (ConferenceRegistration, error)
+	// Marks the token as used: a token already used returns no rows, so it works once
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
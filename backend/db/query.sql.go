@@ -37,10 +37,22 @@ func (q *Queries) CancelRegistration(ctx context.Context, id uuid.UUID) (Confere
 	return i, err
 }
 
+const countConfirmedRegistrations = `-- name: CountConfirmedRegistrations :one
+SELECT COUNT(*) FROM conference_registrations
+WHERE conference_id = $1 AND status IN ('registered', 'attended')
+`
+
+func (q *Queries) CountConfirmedRegistrations(ctx context.Context, conferenceID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countConfirmedRegistrations, conferenceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createConference = `-- name: CreateConference :one
-INSERT INTO conferences (title, date, location, website, latitude, longitude, created_by)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at
+INSERT INTO conferences (title, date, location, website, latitude, longitude, created_by, capacity)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity
 `
 
 type CreateConferenceParams struct {
@@ -51,6 +63,7 @@ type CreateConferenceParams struct {
 	Latitude  sql.NullFloat64
 	Longitude sql.NullFloat64
 	CreatedBy uuid.UUID
+	Capacity  sql.NullInt32
 }
 
 func (q *Queries) CreateConference(ctx context.Context, arg CreateConferenceParams) (Conference, error) {
@@ -62,6 +75,7 @@ func (q *Queries) CreateConference(ctx context.Context, arg CreateConferencePara
 		arg.Latitude,
 		arg.Longitude,
 		arg.CreatedBy,
+		arg.Capacity,
 	)
 	var i Conference
 	err := row.Scan(
@@ -75,6 +89,131 @@ func (q *Queries) CreateConference(ctx context.Context, arg CreateConferencePara
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Capacity,
+	)
+	return i, err
+}
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, created_at, expires_at, used_at
+`
+
+type CreatePasswordResetTokenParams struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordResetToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+	)
+	return i, err
+}
+
+const createRideOffer = `-- name: CreateRideOffer :one
+INSERT INTO ride_offers (conference_id, driver_id, seats, departure_city, departure_time, departure_latitude, departure_longitude, notes)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (conference_id, driver_id) DO NOTHING
+RETURNING id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at
+`
+
+type CreateRideOfferParams struct {
+	ConferenceID       uuid.UUID
+	DriverID           uuid.UUID
+	Seats              int32
+	DepartureCity      string
+	DepartureTime      time.Time
+	DepartureLatitude  sql.NullFloat64
+	DepartureLongitude sql.NullFloat64
+	Notes              sql.NullString
+}
+
+// An existing offer by the same driver makes the insert return no rows
+func (q *Queries) CreateRideOffer(ctx context.Context, arg CreateRideOfferParams) (RideOffer, error) {
+	row := q.db.QueryRowContext(ctx, createRideOffer,
+		arg.ConferenceID,
+		arg.DriverID,
+		arg.Seats,
+		arg.DepartureCity,
+		arg.DepartureTime,
+		arg.DepartureLatitude,
+		arg.DepartureLongitude,
+		arg.Notes,
+	)
+	var i RideOffer
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.DriverID,
+		&i.Seats,
+		&i.SeatsTaken,
+		&i.DepartureCity,
+		&i.DepartureTime,
+		&i.DepartureLatitude,
+		&i.DepartureLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRideRequest = `-- name: CreateRideRequest :one
+INSERT INTO ride_requests (conference_id, passenger_id, pickup_city, pickup_latitude, pickup_longitude, notes)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (conference_id, passenger_id) DO UPDATE SET
+    status = 'open',
+    offer_id = NULL,
+    pickup_city = EXCLUDED.pickup_city,
+    pickup_latitude = EXCLUDED.pickup_latitude,
+    pickup_longitude = EXCLUDED.pickup_longitude,
+    notes = EXCLUDED.notes,
+    updated_at = NOW()
+WHERE ride_requests.status = 'cancelled'
+RETURNING id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at
+`
+
+type CreateRideRequestParams struct {
+	ConferenceID    uuid.UUID
+	PassengerID     uuid.UUID
+	PickupCity      sql.NullString
+	PickupLatitude  sql.NullFloat64
+	PickupLongitude sql.NullFloat64
+	Notes           sql.NullString
+}
+
+// A cancelled request is reopened in place; an active one makes the insert return no rows
+func (q *Queries) CreateRideRequest(ctx context.Context, arg CreateRideRequestParams) (RideRequest, error) {
+	row := q.db.QueryRowContext(ctx, createRideRequest,
+		arg.ConferenceID,
+		arg.PassengerID,
+		arg.PickupCity,
+		arg.PickupLatitude,
+		arg.PickupLongitude,
+		arg.Notes,
+	)
+	var i RideRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.PassengerID,
+		&i.OfferID,
+		&i.Status,
+		&i.PickupCity,
+		&i.PickupLatitude,
+		&i.PickupLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -90,7 +229,7 @@ type CreateTokenParams struct {
 	TokenHash string
 }
 
-// Token management queries (user_tokens table must be present in schema.sql)
+// Token management queries (user_tokens table is created by the baseline migration)
 func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) (UserToken, error) {
 	row := q.db.QueryRowContext(ctx, createToken, arg.UserID, arg.TokenHash)
 	var i UserToken
@@ -108,7 +247,7 @@ func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) (UserT
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password, name, nickname, city, avatar_url, bio)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at
 `
 
 type CreateUserParams struct {
@@ -143,6 +282,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -183,6 +324,39 @@ func (q *Queries) DeleteConference(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteExpiredPasswordResetTokens = `-- name: DeleteExpiredPasswordResetTokens :execrows
+DELETE FROM password_reset_tokens
+WHERE used_at IS NOT NULL OR expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredPasswordResetTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredPasswordResetTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredTokens = `-- name: DeleteExpiredTokens :execrows
+DELETE FROM user_tokens
+WHERE revoked = TRUE
+   OR created_at < $1::timestamptz
+   OR COALESCE(last_used_at, created_at) < $2::timestamptz
+`
+
+type DeleteExpiredTokensParams struct {
+	CreatedBefore time.Time
+	UsedBefore    time.Time
+}
+
+func (q *Queries) DeleteExpiredTokens(ctx context.Context, arg DeleteExpiredTokensParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredTokens, arg.CreatedBefore, arg.UsedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteRegistration = `-- name: DeleteRegistration :exec
 DELETE FROM conference_registrations WHERE user_id = $1 AND conference_id = $2
 `
@@ -197,6 +371,15 @@ func (q *Queries) DeleteRegistration(ctx context.Context, arg DeleteRegistration
 	return err
 }
 
+const deleteRideOffer = `-- name: DeleteRideOffer :exec
+DELETE FROM ride_offers WHERE id = $1
+`
+
+func (q *Queries) DeleteRideOffer(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRideOffer, id)
+	return err
+}
+
 const deleteToken = `-- name: DeleteToken :exec
 DELETE FROM user_tokens WHERE id = $1
 `
@@ -206,8 +389,37 @@ func (q *Queries) DeleteToken(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getConferenceAccess = `-- name: GetConferenceAccess :one
+SELECT
+    u.is_admin,
+    (c.created_by = u.id)::boolean AS is_owner,
+    COALESCE(r.role = 'organizer' AND r.status != 'cancelled', FALSE)::boolean AS is_organizer
+FROM users u
+CROSS JOIN conferences c
+LEFT JOIN conference_registrations r ON r.conference_id = c.id AND r.user_id = u.id
+WHERE u.id = $1 AND c.id = $2
+`
+
+type GetConferenceAccessParams struct {
+	UserID       uuid.UUID
+	ConferenceID uuid.UUID
+}
+
+type GetConferenceAccessRow struct {
+	IsAdmin     bool
+	IsOwner     bool
+	IsOrganizer bool
+}
+
+func (q *Queries) GetConferenceAccess(ctx context.Context, arg GetConferenceAccessParams) (GetConferenceAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getConferenceAccess, arg.UserID, arg.ConferenceID)
+	var i GetConferenceAccessRow
+	err := row.Scan(&i.IsAdmin, &i.IsOwner, &i.IsOrganizer)
+	return i, err
+}
+
 const getConferenceByID = `-- name: GetConferenceByID :one
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences WHERE id = $1
+SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity FROM conferences WHERE id = $1
 `
 
 func (q *Queries) GetConferenceByID(ctx context.Context, id uuid.UUID) (Conference, error) {
@@ -224,28 +436,34 @@ func (q *Queries) GetConferenceByID(ctx context.Context, id uuid.UUID) (Conferen
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Capacity,
 	)
 	return i, err
 }
 
 const getConferenceStats = `-- name: GetConferenceStats :one
 SELECT
-    c.id, c.title,
-    COUNT(r.id) as total_registrations,
+    c.id, c.title, c.capacity,
+    COUNT(r.id) FILTER (WHERE r.status != 'cancelled') as total_registrations,
     COUNT(r.id) FILTER (WHERE r.status = 'registered') as confirmed_count,
+    COUNT(r.id) FILTER (WHERE r.status = 'waitlist') as waitlist_count,
+    COUNT(r.id) FILTER (WHERE r.status = 'cancelled') as cancelled_count,
     COUNT(r.id) FILTER (WHERE r.needs_ride = TRUE AND r.status != 'cancelled') as needing_ride_count,
     COUNT(r.id) FILTER (WHERE r.has_car = TRUE AND r.status != 'cancelled') as offering_ride_count
 FROM conferences c
 LEFT JOIN conference_registrations r ON r.conference_id = c.id
 WHERE c.id = $1
-GROUP BY c.id, c.title
+GROUP BY c.id, c.title, c.capacity
 `
 
 type GetConferenceStatsRow struct {
 	ID                 uuid.UUID
 	Title              string
+	Capacity           sql.NullInt32
 	TotalRegistrations int64
 	ConfirmedCount     int64
+	WaitlistCount      int64
+	CancelledCount     int64
 	NeedingRideCount   int64
 	OfferingRideCount  int64
 }
@@ -256,14 +474,38 @@ func (q *Queries) GetConferenceStats(ctx context.Context, id uuid.UUID) (GetConf
 	err := row.Scan(
 		&i.ID,
 		&i.Title,
+		&i.Capacity,
 		&i.TotalRegistrations,
 		&i.ConfirmedCount,
+		&i.WaitlistCount,
+		&i.CancelledCount,
 		&i.NeedingRideCount,
 		&i.OfferingRideCount,
 	)
 	return i, err
 }
 
+const getPasswordResetToken = `-- name: GetPasswordResetToken :one
+SELECT id, user_id, token_hash, created_at, expires_at, used_at
+FROM password_reset_tokens
+WHERE token_hash = $1 AND used_at IS NULL
+`
+
+// Read-only check of an unused token, done before the password is hashed
+func (q *Queries) GetPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetToken, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+	)
+	return i, err
+}
+
 const getRegistration = `-- name: GetRegistration :one
 SELECT id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at FROM conference_registrations WHERE user_id = $1 AND conference_id = $2
 `
@@ -297,8 +539,15 @@ SELECT r.id, r.user_id, r.conference_id, r.status, r.role, r.notes, r.needs_ride
 FROM conference_registrations r
 JOIN users u ON u.id = r.user_id
 WHERE r.conference_id = $1
+  AND ($2::boolean OR r.status != 'cancelled')
+ORDER BY r.registered_at ASC
 `
 
+type GetRegistrationsByConferenceParams struct {
+	ConferenceID     uuid.UUID
+	IncludeCancelled bool
+}
+
 type GetRegistrationsByConferenceRow struct {
 	ID           uuid.UUID
 	UserID       uuid.UUID
@@ -317,8 +566,8 @@ type GetRegistrationsByConferenceRow struct {
 	AvatarUrl    sql.NullString
 }
 
-func (q *Queries) GetRegistrationsByConference(ctx context.Context, conferenceID uuid.UUID) ([]GetRegistrationsByConferenceRow, error) {
-	rows, err := q.db.QueryContext(ctx, getRegistrationsByConference, conferenceID)
+func (q *Queries) GetRegistrationsByConference(ctx context.Context, arg GetRegistrationsByConferenceParams) ([]GetRegistrationsByConferenceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRegistrationsByConference, arg.ConferenceID, arg.IncludeCancelled)
 	if err != nil {
 		return nil, err
 	}
@@ -361,7 +610,7 @@ SELECT r.id, r.user_id, r.conference_id, r.status, r.role, r.notes, r.needs_ride
        c.title, c.date, c.location, c.website
 FROM conference_registrations r
 JOIN conferences c ON c.id = r.conference_id
-WHERE r.user_id = $1
+WHERE r.user_id = $1 AND r.status != 'cancelled'
 `
 
 type GetRegistrationsByUserRow struct {
@@ -419,6 +668,91 @@ func (q *Queries) GetRegistrationsByUser(ctx context.Context, userID uuid.UUID)
 	return items, nil
 }
 
+const getRideOffer = `-- name: GetRideOffer :one
+SELECT id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at
+FROM ride_offers
+WHERE id = $1
+`
+
+func (q *Queries) GetRideOffer(ctx context.Context, id uuid.UUID) (RideOffer, error) {
+	row := q.db.QueryRowContext(ctx, getRideOffer, id)
+	var i RideOffer
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.DriverID,
+		&i.Seats,
+		&i.SeatsTaken,
+		&i.DepartureCity,
+		&i.DepartureTime,
+		&i.DepartureLatitude,
+		&i.DepartureLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRideOfferByDriver = `-- name: GetRideOfferByDriver :one
+SELECT id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at
+FROM ride_offers
+WHERE conference_id = $1 AND driver_id = $2
+`
+
+type GetRideOfferByDriverParams struct {
+	ConferenceID uuid.UUID
+	DriverID     uuid.UUID
+}
+
+func (q *Queries) GetRideOfferByDriver(ctx context.Context, arg GetRideOfferByDriverParams) (RideOffer, error) {
+	row := q.db.QueryRowContext(ctx, getRideOfferByDriver, arg.ConferenceID, arg.DriverID)
+	var i RideOffer
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.DriverID,
+		&i.Seats,
+		&i.SeatsTaken,
+		&i.DepartureCity,
+		&i.DepartureTime,
+		&i.DepartureLatitude,
+		&i.DepartureLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRideRequestByPassenger = `-- name: GetRideRequestByPassenger :one
+SELECT id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at
+FROM ride_requests
+WHERE conference_id = $1 AND passenger_id = $2
+`
+
+type GetRideRequestByPassengerParams struct {
+	ConferenceID uuid.UUID
+	PassengerID  uuid.UUID
+}
+
+func (q *Queries) GetRideRequestByPassenger(ctx context.Context, arg GetRideRequestByPassengerParams) (RideRequest, error) {
+	row := q.db.QueryRowContext(ctx, getRideRequestByPassenger, arg.ConferenceID, arg.PassengerID)
+	var i RideRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.PassengerID,
+		&i.OfferID,
+		&i.Status,
+		&i.PickupCity,
+		&i.PickupLatitude,
+		&i.PickupLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getTokenByHash = `-- name: GetTokenByHash :one
 SELECT id, user_id, token_hash, created_at, last_used_at, revoked
 FROM user_tokens
@@ -477,7 +811,7 @@ func (q *Queries) GetTokensByUser(ctx context.Context, userID uuid.UUID) ([]User
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at FROM users WHERE email = $1
+SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -494,12 +828,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at FROM users WHERE id = $1
+SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -516,16 +852,84 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
+const invalidatePasswordResetTokens = `-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, invalidatePasswordResetTokens, userID)
+	return err
+}
+
+const isRideDeclined = `-- name: IsRideDeclined :one
+SELECT EXISTS (
+    SELECT 1 FROM ride_declines WHERE offer_id = $1 AND passenger_id = $2
+)
+`
+
+type IsRideDeclinedParams struct {
+	OfferID     uuid.UUID
+	PassengerID uuid.UUID
+}
+
+func (q *Queries) IsRideDeclined(ctx context.Context, arg IsRideDeclinedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isRideDeclined, arg.OfferID, arg.PassengerID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listConferences = `-- name: ListConferences :many
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences ORDER BY date DESC
+SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity
+FROM conferences
+WHERE ($1::boolean IS NULL OR (date >= NOW()) = $1::boolean)
+  AND ($2::timestamptz IS NULL OR date >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR date <= $3::timestamptz)
+  AND ($4::text IS NULL OR location ILIKE '%' || $4::text || '%')
+  AND ($5::text IS NULL OR to_tsvector('simple', title || ' ' || location) @@ websearch_to_tsquery('simple', $5::text))
+  AND ($6::timestamptz IS NULL
+       OR ($7::boolean AND (date, id) < ($6::timestamptz, $8::uuid))
+       OR (NOT $7::boolean AND (date, id) > ($6::timestamptz, $8::uuid)))
+ORDER BY
+    CASE WHEN $7::boolean THEN date END DESC,
+    CASE WHEN $7::boolean THEN id END DESC,
+    date ASC,
+    id ASC
+LIMIT $9
 `
 
-func (q *Queries) ListConferences(ctx context.Context) ([]Conference, error) {
-	rows, err := q.db.QueryContext(ctx, listConferences)
+type ListConferencesParams struct {
+	Upcoming   sql.NullBool
+	FromDate   sql.NullTime
+	ToDate     sql.NullTime
+	Location   sql.NullString
+	Search     sql.NullString
+	CursorDate sql.NullTime
+	Descending bool
+	CursorID   uuid.NullUUID
+	PageLimit  int32
+}
+
+// Filters are optional; the cursor is the (date, id) of the last row of the previous page
+func (q *Queries) ListConferences(ctx context.Context, arg ListConferencesParams) ([]Conference, error) {
+	rows, err := q.db.QueryContext(ctx, listConferences,
+		arg.Upcoming,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Location,
+		arg.Search,
+		arg.CursorDate,
+		arg.Descending,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -544,6 +948,7 @@ func (q *Queries) ListConferences(ctx context.Context) ([]Conference, error) {
 			&i.CreatedBy,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Capacity,
 		); err != nil {
 			return nil, err
 		}
@@ -558,19 +963,69 @@ func (q *Queries) ListConferences(ctx context.Context) ([]Conference, error) {
 	return items, nil
 }
 
-const listConferencesByLocation = `-- name: ListConferencesByLocation :many
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences WHERE location ILIKE $1 ORDER BY date DESC
+const listConferencesNearby = `-- name: ListConferencesNearby :many
+SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity, distance_km
+FROM (
+    SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity,
+           (2 * 6371 * asin(sqrt(
+               power(sin(radians(latitude - $1::float8) / 2), 2) +
+               cos(radians($1::float8)) * cos(radians(latitude)) *
+               power(sin(radians(longitude - $2::float8) / 2), 2)
+           )))::float8 AS distance_km
+    FROM conferences
+    WHERE latitude BETWEEN $3::float8 AND $4::float8
+      AND longitude BETWEEN $5::float8 AND $6::float8
+) nearby
+WHERE distance_km <= $7::float8
+ORDER BY distance_km ASC, date ASC
+LIMIT $8
 `
 
-func (q *Queries) ListConferencesByLocation(ctx context.Context, location string) ([]Conference, error) {
-	rows, err := q.db.QueryContext(ctx, listConferencesByLocation, location)
+type ListConferencesNearbyParams struct {
+	Lat       float64
+	Lng       float64
+	MinLat    float64
+	MaxLat    float64
+	MinLng    float64
+	MaxLng    float64
+	RadiusKm  float64
+	PageLimit int32
+}
+
+type ListConferencesNearbyRow struct {
+	ID         uuid.UUID
+	Title      string
+	Date       time.Time
+	Location   string
+	Website    sql.NullString
+	Latitude   sql.NullFloat64
+	Longitude  sql.NullFloat64
+	CreatedBy  uuid.UUID
+	CreatedAt  sql.NullTime
+	UpdatedAt  sql.NullTime
+	Capacity   sql.NullInt32
+	DistanceKm float64
+}
+
+// The bounding box prefilter uses idx_conferences_coordinates; the haversine distance is exact
+func (q *Queries) ListConferencesNearby(ctx context.Context, arg ListConferencesNearbyParams) ([]ListConferencesNearbyRow, error) {
+	rows, err := q.db.QueryContext(ctx, listConferencesNearby,
+		arg.Lat,
+		arg.Lng,
+		arg.MinLat,
+		arg.MaxLat,
+		arg.MinLng,
+		arg.MaxLng,
+		arg.RadiusKm,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Conference
+	var items []ListConferencesNearbyRow
 	for rows.Next() {
-		var i Conference
+		var i ListConferencesNearbyRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Title,
@@ -582,6 +1037,8 @@ func (q *Queries) ListConferencesByLocation(ctx context.Context, location string
 			&i.CreatedBy,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Capacity,
+			&i.DistanceKm,
 		); err != nil {
 			return nil, err
 		}
@@ -596,34 +1053,31 @@ func (q *Queries) ListConferencesByLocation(ctx context.Context, location string
 	return items, nil
 }
 
-const listUpcomingConferences = `-- name: ListUpcomingConferences :many
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences WHERE date >= NOW() ORDER BY date ASC
+const listDeclinedRideOffers = `-- name: ListDeclinedRideOffers :many
+SELECT d.offer_id
+FROM ride_declines d
+JOIN ride_offers o ON o.id = d.offer_id
+WHERE o.conference_id = $1 AND d.passenger_id = $2
 `
 
-func (q *Queries) ListUpcomingConferences(ctx context.Context) ([]Conference, error) {
-	rows, err := q.db.QueryContext(ctx, listUpcomingConferences)
+type ListDeclinedRideOffersParams struct {
+	ConferenceID uuid.UUID
+	PassengerID  uuid.UUID
+}
+
+func (q *Queries) ListDeclinedRideOffers(ctx context.Context, arg ListDeclinedRideOffersParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listDeclinedRideOffers, arg.ConferenceID, arg.PassengerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Conference
+	var items []uuid.UUID
 	for rows.Next() {
-		var i Conference
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Date,
-			&i.Location,
-			&i.Website,
-			&i.Latitude,
-			&i.Longitude,
-			&i.CreatedBy,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
+		var offer_id uuid.UUID
+		if err := rows.Scan(&offer_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, offer_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -634,54 +1088,59 @@ func (q *Queries) ListUpcomingConferences(ctx context.Context) ([]Conference, er
 	return items, nil
 }
 
-const listUsersNeedingRide = `-- name: ListUsersNeedingRide :many
-SELECT u.id, u.email, u.password, u.name, u.nickname, u.city, u.avatar_url, u.bio, u.created_at, u.updated_at,
-       c.title, c.location, r.notes
-FROM conference_registrations r
-JOIN users u ON u.id = r.user_id
-JOIN conferences c ON c.id = r.conference_id
-WHERE r.conference_id = $1 AND r.needs_ride = TRUE AND r.status != 'cancelled'
+const listRideOffersByConference = `-- name: ListRideOffersByConference :many
+SELECT o.id, o.conference_id, o.driver_id, o.seats, o.seats_taken, o.departure_city, o.departure_time, o.departure_latitude, o.departure_longitude, o.notes, o.created_at,
+       u.email, u.name, u.nickname, u.city
+FROM ride_offers o
+JOIN users u ON u.id = o.driver_id
+JOIN conference_registrations cr ON cr.conference_id = o.conference_id AND cr.user_id = o.driver_id AND cr.status != 'cancelled'
+WHERE o.conference_id = $1
+ORDER BY o.departure_time ASC
 `
 
-type ListUsersNeedingRideRow struct {
-	ID        uuid.UUID
-	Email     string
-	Password  string
-	Name      string
-	Nickname  sql.NullString
-	City      sql.NullString
-	AvatarUrl sql.NullString
-	Bio       sql.NullString
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	Title     string
-	Location  string
-	Notes     sql.NullString
+type ListRideOffersByConferenceRow struct {
+	ID                 uuid.UUID
+	ConferenceID       uuid.UUID
+	DriverID           uuid.UUID
+	Seats              int32
+	SeatsTaken         int32
+	DepartureCity      string
+	DepartureTime      time.Time
+	DepartureLatitude  sql.NullFloat64
+	DepartureLongitude sql.NullFloat64
+	Notes              sql.NullString
+	CreatedAt          sql.NullTime
+	Email              string
+	Name               string
+	Nickname           sql.NullString
+	City               sql.NullString
 }
 
-func (q *Queries) ListUsersNeedingRide(ctx context.Context, conferenceID uuid.UUID) ([]ListUsersNeedingRideRow, error) {
-	rows, err := q.db.QueryContext(ctx, listUsersNeedingRide, conferenceID)
+func (q *Queries) ListRideOffersByConference(ctx context.Context, conferenceID uuid.UUID) ([]ListRideOffersByConferenceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRideOffersByConference, conferenceID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListUsersNeedingRideRow
+	var items []ListRideOffersByConferenceRow
 	for rows.Next() {
-		var i ListUsersNeedingRideRow
+		var i ListRideOffersByConferenceRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.ConferenceID,
+			&i.DriverID,
+			&i.Seats,
+			&i.SeatsTaken,
+			&i.DepartureCity,
+			&i.DepartureTime,
+			&i.DepartureLatitude,
+			&i.DepartureLongitude,
+			&i.Notes,
+			&i.CreatedAt,
 			&i.Email,
-			&i.Password,
 			&i.Name,
 			&i.Nickname,
 			&i.City,
-			&i.AvatarUrl,
-			&i.Bio,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Title,
-			&i.Location,
-			&i.Notes,
 		); err != nil {
 			return nil, err
 		}
@@ -696,50 +1155,170 @@ func (q *Queries) ListUsersNeedingRide(ctx context.Context, conferenceID uuid.UU
 	return items, nil
 }
 
-const listUsersOfferingRide = `-- name: ListUsersOfferingRide :many
-SELECT u.id, u.email, u.password, u.name, u.nickname, u.city, u.avatar_url, u.bio, u.created_at, u.updated_at,
-       c.title, c.location, r.notes
-FROM conference_registrations r
-JOIN users u ON u.id = r.user_id
-JOIN conferences c ON c.id = r.conference_id
-WHERE r.conference_id = $1 AND r.has_car = TRUE AND r.status != 'cancelled'
+const listRideRequestsByOffer = `-- name: ListRideRequestsByOffer :many
+SELECT r.id, r.conference_id, r.passenger_id, r.offer_id, r.status, r.pickup_city, r.pickup_latitude, r.pickup_longitude, r.notes, r.created_at, r.updated_at,
+       u.email, u.name, u.nickname, u.city
+FROM ride_requests r
+JOIN users u ON u.id = r.passenger_id
+JOIN conference_registrations cr ON cr.conference_id = r.conference_id AND cr.user_id = r.passenger_id AND cr.status != 'cancelled'
+WHERE r.offer_id = $1 AND r.status IN ('pending', 'accepted')
+ORDER BY r.created_at ASC
 `
 
-type ListUsersOfferingRideRow struct {
-	ID        uuid.UUID
-	Email     string
-	Password  string
-	Name      string
-	Nickname  sql.NullString
-	City      sql.NullString
-	AvatarUrl sql.NullString
-	Bio       sql.NullString
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	Title     string
-	Location  string
-	Notes     sql.NullString
+type ListRideRequestsByOfferRow struct {
+	ID              uuid.UUID
+	ConferenceID    uuid.UUID
+	PassengerID     uuid.UUID
+	OfferID         uuid.NullUUID
+	Status          string
+	PickupCity      sql.NullString
+	PickupLatitude  sql.NullFloat64
+	PickupLongitude sql.NullFloat64
+	Notes           sql.NullString
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	Email           string
+	Name            string
+	Nickname        sql.NullString
+	City            sql.NullString
 }
 
-func (q *Queries) ListUsersOfferingRide(ctx context.Context, conferenceID uuid.UUID) ([]ListUsersOfferingRideRow, error) {
-	rows, err := q.db.QueryContext(ctx, listUsersOfferingRide, conferenceID)
+func (q *Queries) ListRideRequestsByOffer(ctx context.Context, offerID uuid.NullUUID) ([]ListRideRequestsByOfferRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRideRequestsByOffer, offerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListUsersOfferingRideRow
+	var items []ListRideRequestsByOfferRow
 	for rows.Next() {
-		var i ListUsersOfferingRideRow
+		var i ListRideRequestsByOfferRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.ConferenceID,
+			&i.PassengerID,
+			&i.OfferID,
+			&i.Status,
+			&i.PickupCity,
+			&i.PickupLatitude,
+			&i.PickupLongitude,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 			&i.Email,
-			&i.Password,
 			&i.Name,
 			&i.Nickname,
 			&i.City,
-			&i.AvatarUrl,
-			&i.Bio,
-			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUpcomingConferenceMetrics = `-- name: ListUpcomingConferenceMetrics :many
+SELECT
+    c.id, c.title,
+    COUNT(r.id) FILTER (WHERE r.status = 'registered') as confirmed_count,
+    COUNT(r.id) FILTER (WHERE r.status = 'waitlist') as waitlist_count,
+    (SELECT COUNT(*) FROM ride_offers o WHERE o.conference_id = c.id) as rides_offered,
+    (SELECT COUNT(*) FROM ride_requests q WHERE q.conference_id = c.id AND q.status IN ('open', 'pending')) as rides_needed
+FROM conferences c
+LEFT JOIN conference_registrations r ON r.conference_id = c.id
+WHERE c.date >= NOW()
+GROUP BY c.id, c.title
+ORDER BY c.date ASC
+`
+
+type ListUpcomingConferenceMetricsRow struct {
+	ID             uuid.UUID
+	Title          string
+	ConfirmedCount int64
+	WaitlistCount  int64
+	RidesOffered   int64
+	RidesNeeded    int64
+}
+
+func (q *Queries) ListUpcomingConferenceMetrics(ctx context.Context) ([]ListUpcomingConferenceMetricsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUpcomingConferenceMetrics)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUpcomingConferenceMetricsRow
+	for rows.Next() {
+		var i ListUpcomingConferenceMetricsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.ConfirmedCount,
+			&i.WaitlistCount,
+			&i.RidesOffered,
+			&i.RidesNeeded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersNeedingRide = `-- name: ListUsersNeedingRide :many
+SELECT u.id, u.email, u.password, u.name, u.nickname, u.city, u.avatar_url, u.bio, u.created_at, u.updated_at,
+       c.title, c.location, r.notes
+FROM conference_registrations r
+JOIN users u ON u.id = r.user_id
+JOIN conferences c ON c.id = r.conference_id
+WHERE r.conference_id = $1 AND r.needs_ride = TRUE AND r.status != 'cancelled'
+`
+
+type ListUsersNeedingRideRow struct {
+	ID        uuid.UUID
+	Email     string
+	Password  string
+	Name      string
+	Nickname  sql.NullString
+	City      sql.NullString
+	AvatarUrl sql.NullString
+	Bio       sql.NullString
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+	Title     string
+	Location  string
+	Notes     sql.NullString
+}
+
+func (q *Queries) ListUsersNeedingRide(ctx context.Context, conferenceID uuid.UUID) ([]ListUsersNeedingRideRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersNeedingRide, conferenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersNeedingRideRow
+	for rows.Next() {
+		var i ListUsersNeedingRideRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Password,
+			&i.Name,
+			&i.Nickname,
+			&i.City,
+			&i.AvatarUrl,
+			&i.Bio,
+			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Title,
 			&i.Location,
@@ -758,9 +1337,200 @@ func (q *Queries) ListUsersOfferingRide(ctx context.Context, conferenceID uuid.U
 	return items, nil
 }
 
+const listUsersOfferingRide = `-- name: ListUsersOfferingRide :many
+SELECT u.id, u.email, u.password, u.name, u.nickname, u.city, u.avatar_url, u.bio, u.created_at, u.updated_at,
+       c.title, c.location, r.notes
+FROM conference_registrations r
+JOIN users u ON u.id = r.user_id
+JOIN conferences c ON c.id = r.conference_id
+WHERE r.conference_id = $1 AND r.has_car = TRUE AND r.status != 'cancelled'
+`
+
+type ListUsersOfferingRideRow struct {
+	ID        uuid.UUID
+	Email     string
+	Password  string
+	Name      string
+	Nickname  sql.NullString
+	City      sql.NullString
+	AvatarUrl sql.NullString
+	Bio       sql.NullString
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+	Title     string
+	Location  string
+	Notes     sql.NullString
+}
+
+func (q *Queries) ListUsersOfferingRide(ctx context.Context, conferenceID uuid.UUID) ([]ListUsersOfferingRideRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersOfferingRide, conferenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersOfferingRideRow
+	for rows.Next() {
+		var i ListUsersOfferingRideRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Password,
+			&i.Name,
+			&i.Nickname,
+			&i.City,
+			&i.AvatarUrl,
+			&i.Bio,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Location,
+			&i.Notes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockConferenceCapacity = `-- name: LockConferenceCapacity :one
+SELECT capacity FROM conferences WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) LockConferenceCapacity(ctx context.Context, id uuid.UUID) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, lockConferenceCapacity, id)
+	var capacity sql.NullInt32
+	err := row.Scan(&capacity)
+	return capacity, err
+}
+
+const lockRideRequest = `-- name: LockRideRequest :one
+SELECT id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at
+FROM ride_requests
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockRideRequest(ctx context.Context, id uuid.UUID) (RideRequest, error) {
+	row := q.db.QueryRowContext(ctx, lockRideRequest, id)
+	var i RideRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.PassengerID,
+		&i.OfferID,
+		&i.Status,
+		&i.PickupCity,
+		&i.PickupLatitude,
+		&i.PickupLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const promoteNextWaitlisted = `-- name: PromoteNextWaitlisted :one
+UPDATE conference_registrations SET status = 'registered'
+WHERE id = (
+    SELECT w.id FROM conference_registrations w
+    WHERE w.conference_id = $1 AND w.status = 'waitlist'
+    ORDER BY w.registered_at ASC
+    LIMIT 1
+)
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at
+`
+
+func (q *Queries) PromoteNextWaitlisted(ctx context.Context, conferenceID uuid.UUID) (ConferenceRegistration, error) {
+	row := q.db.QueryRowContext(ctx, promoteNextWaitlisted, conferenceID)
+	var i ConferenceRegistration
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ConferenceID,
+		&i.Status,
+		&i.Role,
+		&i.Notes,
+		&i.NeedsRide,
+		&i.HasCar,
+		&i.RegisteredAt,
+		&i.CancelledAt,
+	)
+	return i, err
+}
+
+const reactivateRegistration = `-- name: ReactivateRegistration :one
+UPDATE conference_registrations SET
+    status = $2,
+    role = $3,
+    notes = $4,
+    needs_ride = $5,
+    has_car = $6,
+    registered_at = NOW(),
+    cancelled_at = NULL
+WHERE id = $1
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at
+`
+
+type ReactivateRegistrationParams struct {
+	ID        uuid.UUID
+	Status    string
+	Role      string
+	Notes     sql.NullString
+	NeedsRide sql.NullBool
+	HasCar    sql.NullBool
+}
+
+func (q *Queries) ReactivateRegistration(ctx context.Context, arg ReactivateRegistrationParams) (ConferenceRegistration, error) {
+	row := q.db.QueryRowContext(ctx, reactivateRegistration,
+		arg.ID,
+		arg.Status,
+		arg.Role,
+		arg.Notes,
+		arg.NeedsRide,
+		arg.HasCar,
+	)
+	var i ConferenceRegistration
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ConferenceID,
+		&i.Status,
+		&i.Role,
+		&i.Notes,
+		&i.NeedsRide,
+		&i.HasCar,
+		&i.RegisteredAt,
+		&i.CancelledAt,
+	)
+	return i, err
+}
+
+const recordRideDecline = `-- name: RecordRideDecline :exec
+INSERT INTO ride_declines (offer_id, passenger_id)
+VALUES ($1, $2)
+ON CONFLICT (offer_id, passenger_id) DO NOTHING
+`
+
+type RecordRideDeclineParams struct {
+	OfferID     uuid.UUID
+	PassengerID uuid.UUID
+}
+
+func (q *Queries) RecordRideDecline(ctx context.Context, arg RecordRideDeclineParams) error {
+	_, err := q.db.ExecContext(ctx, recordRideDecline, arg.OfferID, arg.PassengerID)
+	return err
+}
+
 const registerUserToConference = `-- name: RegisterUserToConference :one
-INSERT INTO conference_registrations (user_id, conference_id, role, notes, needs_ride, has_car)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO conference_registrations (user_id, conference_id, role, notes, needs_ride, has_car, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at
 `
 
@@ -771,6 +1541,7 @@ type RegisterUserToConferenceParams struct {
 	Notes        sql.NullString
 	NeedsRide    sql.NullBool
 	HasCar       sql.NullBool
+	Status       string
 }
 
 func (q *Queries) RegisterUserToConference(ctx context.Context, arg RegisterUserToConferenceParams) (ConferenceRegistration, error) {
@@ -781,7 +1552,47 @@ func (q *Queries) RegisterUserToConference(ctx context.Context, arg RegisterUser
 		arg.Notes,
 		arg.NeedsRide,
 		arg.HasCar,
+		arg.Status,
+	)
+	var i ConferenceRegistration
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ConferenceID,
+		&i.Status,
+		&i.Role,
+		&i.Notes,
+		&i.NeedsRide,
+		&i.HasCar,
+		&i.RegisteredAt,
+		&i.CancelledAt,
 	)
+	return i, err
+}
+
+const releaseRideSeat = `-- name: ReleaseRideSeat :exec
+UPDATE ride_offers SET seats_taken = seats_taken - 1
+WHERE id = $1 AND seats_taken > 0
+`
+
+func (q *Queries) ReleaseRideSeat(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, releaseRideSeat, id)
+	return err
+}
+
+const removeConferenceOrganizer = `-- name: RemoveConferenceOrganizer :one
+UPDATE conference_registrations SET role = 'attendee'
+WHERE user_id = $1 AND conference_id = $2 AND role = 'organizer'
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at
+`
+
+type RemoveConferenceOrganizerParams struct {
+	UserID       uuid.UUID
+	ConferenceID uuid.UUID
+}
+
+func (q *Queries) RemoveConferenceOrganizer(ctx context.Context, arg RemoveConferenceOrganizerParams) (ConferenceRegistration, error) {
+	row := q.db.QueryRowContext(ctx, removeConferenceOrganizer, arg.UserID, arg.ConferenceID)
 	var i ConferenceRegistration
 	err := row.Scan(
 		&i.ID,
@@ -798,6 +1609,91 @@ func (q *Queries) RegisterUserToConference(ctx context.Context, arg RegisterUser
 	return i, err
 }
 
+const reopenRideRequestsByOffer = `-- name: ReopenRideRequestsByOffer :many
+UPDATE ride_requests SET status = 'open', offer_id = NULL, updated_at = NOW()
+WHERE offer_id = $1 AND status IN ('pending', 'accepted')
+RETURNING passenger_id
+`
+
+func (q *Queries) ReopenRideRequestsByOffer(ctx context.Context, offerID uuid.NullUUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, reopenRideRequestsByOffer, offerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var passenger_id uuid.UUID
+		if err := rows.Scan(&passenger_id); err != nil {
+			return nil, err
+		}
+		items = append(items, passenger_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reserveRideSeat = `-- name: ReserveRideSeat :one
+UPDATE ride_offers SET seats_taken = seats_taken + 1
+WHERE id = $1 AND seats_taken < seats
+RETURNING id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at
+`
+
+func (q *Queries) ReserveRideSeat(ctx context.Context, id uuid.UUID) (RideOffer, error) {
+	row := q.db.QueryRowContext(ctx, reserveRideSeat, id)
+	var i RideOffer
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.DriverID,
+		&i.Seats,
+		&i.SeatsTaken,
+		&i.DepartureCity,
+		&i.DepartureTime,
+		&i.DepartureLatitude,
+		&i.DepartureLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const revokeAllUserTokens = `-- name: RevokeAllUserTokens :execrows
+UPDATE user_tokens SET revoked = true
+WHERE user_id = $1 AND revoked = FALSE
+`
+
+func (q *Queries) RevokeAllUserTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAllUserTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeOtherUserTokens = `-- name: RevokeOtherUserTokens :execrows
+UPDATE user_tokens SET revoked = true
+WHERE user_id = $1 AND id != $2 AND revoked = FALSE
+`
+
+type RevokeOtherUserTokensParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+func (q *Queries) RevokeOtherUserTokens(ctx context.Context, arg RevokeOtherUserTokensParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeOtherUserTokens, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const revokeToken = `-- name: RevokeToken :one
 UPDATE user_tokens SET revoked = true WHERE id = $1 RETURNING id, user_id, token_hash, created_at, last_used_at, revoked
 `
@@ -816,38 +1712,139 @@ func (q *Queries) RevokeToken(ctx context.Context, id uuid.UUID) (UserToken, err
 	return i, err
 }
 
+const revokeUserToken = `-- name: RevokeUserToken :one
+UPDATE user_tokens SET revoked = true
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, token_hash, created_at, last_used_at, revoked
+`
+
+type RevokeUserTokenParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeUserToken(ctx context.Context, arg RevokeUserTokenParams) (UserToken, error) {
+	row := q.db.QueryRowContext(ctx, revokeUserToken, arg.ID, arg.UserID)
+	var i UserToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.Revoked,
+	)
+	return i, err
+}
+
+const setRegistrationRideFlags = `-- name: SetRegistrationRideFlags :exec
+UPDATE conference_registrations SET
+    needs_ride = COALESCE($1, needs_ride),
+    has_car = COALESCE($2, has_car)
+WHERE user_id = $3 AND conference_id = $4
+`
+
+type SetRegistrationRideFlagsParams struct {
+	NeedsRide    sql.NullBool
+	HasCar       sql.NullBool
+	UserID       uuid.UUID
+	ConferenceID uuid.UUID
+}
+
+func (q *Queries) SetRegistrationRideFlags(ctx context.Context, arg SetRegistrationRideFlagsParams) error {
+	_, err := q.db.ExecContext(ctx, setRegistrationRideFlags,
+		arg.NeedsRide,
+		arg.HasCar,
+		arg.UserID,
+		arg.ConferenceID,
+	)
+	return err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :one
+UPDATE users SET is_admin = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at
+`
+
+type SetUserAdminParams struct {
+	ID      uuid.UUID
+	IsAdmin bool
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAdmin, arg.ID, arg.IsAdmin)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.Name,
+		&i.Nickname,
+		&i.City,
+		&i.AvatarUrl,
+		&i.Bio,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const touchToken = `-- name: TouchToken :exec
+UPDATE user_tokens SET last_used_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchToken(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchToken, id)
+	return err
+}
+
 const updateConference = `-- name: UpdateConference :one
 UPDATE conferences SET
-    title = COALESCE($2, title),
-    date = COALESCE($3, date),
-    location = COALESCE($4, location),
-    website = COALESCE($5, website),
-    latitude = COALESCE($6, latitude),
-    longitude = COALESCE($7, longitude),
+    title = COALESCE($1, title),
+    date = COALESCE($2, date),
+    location = COALESCE($3, location),
+    website = CASE WHEN $4::boolean THEN NULL ELSE COALESCE($5, website) END,
+    latitude = CASE WHEN $6::boolean THEN NULL ELSE COALESCE($7, latitude) END,
+    longitude = CASE WHEN $6::boolean THEN NULL ELSE COALESCE($8, longitude) END,
+    capacity = CASE WHEN $9::boolean THEN NULL ELSE COALESCE($10, capacity) END,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at
+WHERE id = $11
+  AND ($12::timestamptz IS NULL OR updated_at = $12::timestamptz)
+RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity
 `
 
 type UpdateConferenceParams struct {
-	ID        uuid.UUID
-	Title     string
-	Date      time.Time
-	Location  string
-	Website   sql.NullString
-	Latitude  sql.NullFloat64
-	Longitude sql.NullFloat64
+	Title            sql.NullString
+	Date             sql.NullTime
+	Location         sql.NullString
+	ClearWebsite     bool
+	Website          sql.NullString
+	ClearCoordinates bool
+	Latitude         sql.NullFloat64
+	Longitude        sql.NullFloat64
+	ClearCapacity    bool
+	Capacity         sql.NullInt32
+	ID               uuid.UUID
+	IfUpdatedAt      sql.NullTime
 }
 
 func (q *Queries) UpdateConference(ctx context.Context, arg UpdateConferenceParams) (Conference, error) {
 	row := q.db.QueryRowContext(ctx, updateConference,
-		arg.ID,
 		arg.Title,
 		arg.Date,
 		arg.Location,
+		arg.ClearWebsite,
 		arg.Website,
+		arg.ClearCoordinates,
 		arg.Latitude,
 		arg.Longitude,
+		arg.ClearCapacity,
+		arg.Capacity,
+		arg.ID,
+		arg.IfUpdatedAt,
 	)
 	var i Conference
 	err := row.Scan(
@@ -861,6 +1858,7 @@ func (q *Queries) UpdateConference(ctx context.Context, arg UpdateConferencePara
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Capacity,
 	)
 	return i, err
 }
@@ -894,6 +1892,37 @@ func (q *Queries) UpdateRegistrationStatus(ctx context.Context, arg UpdateRegist
 	return i, err
 }
 
+const updateRideRequestStatus = `-- name: UpdateRideRequestStatus :one
+UPDATE ride_requests SET status = $2, offer_id = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at
+`
+
+type UpdateRideRequestStatusParams struct {
+	ID      uuid.UUID
+	Status  string
+	OfferID uuid.NullUUID
+}
+
+func (q *Queries) UpdateRideRequestStatus(ctx context.Context, arg UpdateRideRequestStatusParams) (RideRequest, error) {
+	row := q.db.QueryRowContext(ctx, updateRideRequestStatus, arg.ID, arg.Status, arg.OfferID)
+	var i RideRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ConferenceID,
+		&i.PassengerID,
+		&i.OfferID,
+		&i.Status,
+		&i.PickupCity,
+		&i.PickupLatitude,
+		&i.PickupLongitude,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users SET
     name = COALESCE($1, name),
@@ -903,7 +1932,7 @@ UPDATE users SET
     bio = COALESCE($5, bio),
     updated_at = NOW()
 WHERE id = $6
-RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at
 `
 
 type UpdateUserParams struct {
@@ -936,6 +1965,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -943,7 +1974,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users SET password = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at
 `
 
 type UpdateUserPasswordParams struct {
@@ -965,6 +1996,96 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const upsertConferenceOrganizer = `-- name: UpsertConferenceOrganizer :one
+INSERT INTO conference_registrations (user_id, conference_id, role, status)
+VALUES ($1, $2, 'organizer', $3)
+ON CONFLICT (user_id, conference_id) DO UPDATE SET
+    role = 'organizer',
+    status = CASE WHEN conference_registrations.status = 'cancelled' THEN EXCLUDED.status ELSE conference_registrations.status END,
+    registered_at = CASE WHEN conference_registrations.status = 'cancelled' THEN NOW() ELSE conference_registrations.registered_at END,
+    cancelled_at = NULL
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at
+`
+
+type UpsertConferenceOrganizerParams struct {
+	UserID       uuid.UUID
+	ConferenceID uuid.UUID
+	Status       string
+}
+
+// Active registrations keep their status; new and cancelled ones take the status computed from the capacity
+func (q *Queries) UpsertConferenceOrganizer(ctx context.Context, arg UpsertConferenceOrganizerParams) (ConferenceRegistration, error) {
+	row := q.db.QueryRowContext(ctx, upsertConferenceOrganizer, arg.UserID, arg.ConferenceID, arg.Status)
+	var i ConferenceRegistration
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ConferenceID,
+		&i.Status,
+		&i.Role,
+		&i.Notes,
+		&i.NeedsRide,
+		&i.HasCar,
+		&i.RegisteredAt,
+		&i.CancelledAt,
+	)
+	return i, err
+}
+
+const usePasswordResetToken = `-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL
+RETURNING id, user_id, token_hash, created_at, expires_at, used_at
+`
+
+// Marks the token as used: a token already used returns no rows, so it works once
+func (q *Queries) UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordResetToken, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at
+`
+
+type VerifyUserEmailParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.Name,
+		&i.Nickname,
+		&i.City,
+		&i.AvatarUrl,
+		&i.Bio,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsAdmin,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
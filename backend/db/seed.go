@@ -60,7 +60,15 @@ func Seed(ctx context.Context, database interface {
 		if err != nil {
 			return fmt.Errorf("errore creazione utente %s: %w", u.Email, err)
 		}
-		fmt.Printf("Utente creato: %s\n", user.Email)
+		user, err = q.SetUserAdmin(ctx, SetUserAdminParams{ID: user.ID, IsAdmin: true})
+		if err != nil {
+			return fmt.Errorf("errore assegnazione ruolo admin a %s: %w", user.Email, err)
+		}
+		user, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+		if err != nil {
+			return fmt.Errorf("errore verifica email di %s: %w", user.Email, err)
+		}
+		fmt.Printf("Utente creato: %s (admin)\n", user.Email)
 
 		fmt.Println("Inizio seeding con dati casuali...")
 
@@ -85,6 +93,10 @@ func Seed(ctx context.Context, database interface {
 			if err != nil {
 				return fmt.Errorf("errore creazione utente %s: %w", u.Email, err)
 			}
+			user, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+			if err != nil {
+				return fmt.Errorf("errore verifica email di %s: %w", u.Email, err)
+			}
 			createdUsers = append(createdUsers, user)
 			fmt.Printf("Utente creato: %s\n", user.Email)
 		}
@@ -128,6 +140,7 @@ func Seed(ctx context.Context, database interface {
 					Notes:        nullString(gofakeit.Phrase()),
 					NeedsRide:    nullBool(gofakeit.Bool()),
 					HasCar:       nullBool(gofakeit.Bool()),
+					Status:       "registered",
 				}
 
 				reg, err := q.RegisterUserToConference(ctx, r)
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Error codes returned in ErrorResponse.Code. They are part of the API contract:
+// clients branch on them, so existing codes must not change.
+const (
+	// Generic errors
+	CodeInternal             = "internal_error"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeInvalidBody          = "invalid_body"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeInvalidParameter     = "invalid_parameter"
+	CodeValidationFailed     = "validation_failed"
+	CodeRateLimited          = "rate_limited"
+	CodeOriginNotAllowed     = "origin_not_allowed"
+	CodePreconditionRequired = "precondition_required"
+	CodePreconditionFailed   = "precondition_failed"
+
+	// Authentication and authorization
+	CodeUnauthenticated    = "unauthenticated"
+	CodeInvalidToken       = "invalid_token"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeAdminRequired      = "admin_required"
+	CodeEmailNotVerified   = "email_not_verified"
+
+	// Invalid path identifiers
+	CodeInvalidConferenceID  = "invalid_conference_id"
+	CodeInvalidUserID        = "invalid_user_id"
+	CodeInvalidTokenID       = "invalid_token_id"
+	CodeInvalidRideOfferID   = "invalid_ride_offer_id"
+	CodeInvalidRideRequestID = "invalid_ride_request_id"
+
+	// Domain errors
+	CodeUserNotFound             = "user_not_found"
+	CodeEmailTaken               = "email_taken"
+	CodeEmailAlreadyVerified     = "email_already_verified"
+	CodeInvalidVerificationToken = "invalid_verification_token"
+	CodeVerificationTokenExpired = "verification_token_expired"
+	CodeInvalidResetToken        = "invalid_reset_token"
+	CodeResetTokenExpired        = "reset_token_expired"
+	CodeTokenNotFound            = "token_not_found"
+	CodeConferenceNotFound       = "conference_not_found"
+	CodeConferenceModified       = "conference_modified"
+	CodeOrganizerNotFound        = "organizer_not_found"
+	CodeAlreadyRegistered        = "already_registered"
+	CodeRegistrationNotFound     = "registration_not_found"
+	CodeRegistrationRequired     = "registration_required"
+	CodeRideOfferNotFound        = "ride_offer_not_found"
+	CodeRideOfferExists          = "ride_offer_exists"
+	CodeRideFull                 = "ride_full"
+	CodeRideRequestNotFound      = "ride_request_not_found"
+	CodeRideRequestInvalidState  = "ride_request_invalid_state"
+	CodeRideDeclined             = "ride_declined"
+)
+
+// APIError is an error rendered to clients as an ErrorResponse
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Details []FieldError
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// writeError writes a JSON ErrorResponse with the given status, code and message
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIError(w, &APIError{Status: status, Code: code, Message: message})
+}
+
+// writeAPIError writes err as a JSON ErrorResponse.
+// The request ID is taken from the X-Request-ID header set by requestIDMiddleware.
+func writeAPIError(w http.ResponseWriter, err *APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.Status)
+
+	resp := ErrorResponse{
+		Error:     err.Message,
+		Code:      err.Code,
+		Details:   err.Details,
+		RequestID: w.Header().Get(requestIDHeader),
+	}
+	if encodeErr := json.NewEncoder(w).Encode(resp); encodeErr != nil {
+		slog.Warn("Failed to encode error response", "request_id", resp.RequestID, "error", encodeErr)
+	}
+}
+
+// routeHandler returns a handler serving the routes of mux with serveRoute
+func routeHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveRoute(w, r, mux)
+	})
+}
+
+// serveRoute dispatches r to mux, rendering JSON errors for unknown routes and
+// for methods not registered on a known path instead of the mux's plain text ones
+func serveRoute(w http.ResponseWriter, r *http.Request, mux *http.ServeMux) {
+	if _, pattern := mux.Handler(r); pattern == "" {
+		if methods := routeMethods(mux, r); len(methods) > 0 {
+			w.Header().Set("Allow", joinMethods(methods))
+			writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		writeError(w, http.StatusNotFound, CodeNotFound, "Not found")
+		return
+	}
+	mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// decodeErrorResponse decodifica il body di una risposta di errore
+func decodeErrorResponse(t *testing.T, rr *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected Content-Type application/json, got %q", ct)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	return resp
+}
+
+// Test envelope JSON con codice, dettagli e request ID
+func TestWriteAPIError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set(requestIDHeader, "req-123")
+	writeAPIError(rr, &APIError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    CodeValidationFailed,
+		Message: "Validation failed",
+		Details: []FieldError{{Field: "email", Code: "invalid_email", Message: "must be a valid email address"}},
+	})
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", rr.Code)
+	}
+	resp := decodeErrorResponse(t, rr)
+	if resp.Code != CodeValidationFailed || resp.Error != "Validation failed" || resp.RequestID != "req-123" {
+		t.Errorf("Unexpected error response: %+v", resp)
+	}
+	if len(resp.Details) != 1 || resp.Details[0].Field != "email" {
+		t.Errorf("Expected field details for email, got %+v", resp.Details)
+	}
+}
+
+// Test errori JSON per route sconosciute e metodi non registrati
+func TestServeRouteErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/conferences", func(w http.ResponseWriter, r *http.Request) {})
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveRoute(w, r, mux)
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+		allow  string
+	}{
+		{"Unknown route", "GET", "/api/unknown", http.StatusNotFound, CodeNotFound, ""},
+		{"Method not registered", "DELETE", "/api/conferences", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET, HEAD, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := rr.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Expected Allow %q, got %q", tt.allow, got)
+			}
+			resp := decodeErrorResponse(t, rr)
+			if resp.Code != tt.code {
+				t.Errorf("Expected code %q, got %q", tt.code, resp.Code)
+			}
+			if resp.RequestID == "" || resp.RequestID != rr.Header().Get(requestIDHeader) {
+				t.Errorf("Expected request ID matching header, got %q", resp.RequestID)
+			}
+		})
+	}
+}
+
+// Test riuso dell'X-Request-ID del client solo se ben formato
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = r.Context().Value(RequestIDKey).(string)
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{"No incoming ID", "", false},
+		{"Valid incoming ID", "proxy-42.abc", true},
+		{"Invalid characters", "bad id\nX-Injected: 1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestIDHeader, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			header := rr.Header().Get(requestIDHeader)
+			if header == "" || header != seen {
+				t.Fatalf("Expected same ID in header and context, got %q and %q", header, seen)
+			}
+			if (header == tt.incoming) != tt.reused {
+				t.Errorf("Expected reused=%v, got ID %q", tt.reused, header)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+)
+
+// earthRadiusKm is the mean Earth radius used for great-circle distances
+const earthRadiusKm = 6371.0
+
+// distanceKm returns the great-circle distance between two points using the haversine formula
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// nullDistanceKm returns the distance between two optional points, or nil if any coordinate is missing
+func nullDistanceKm(lat1, lng1, lat2, lng2 sql.NullFloat64) *float64 {
+	if !lat1.Valid || !lng1.Valid || !lat2.Valid || !lng2.Valid {
+		return nil
+	}
+	d := distanceKm(lat1.Float64, lng1.Float64, lat2.Float64, lng2.Float64)
+	return &d
+}
+
+// validateCoordinates checks that optional GPS coordinates are provided together and within range
+func validateCoordinates(latitude, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return errors.New("Latitude and longitude must be provided together")
+	}
+	if latitude != nil && !(*latitude >= -90 && *latitude <= 90) {
+		return errors.New("Latitude must be between -90 and 90")
+	}
+	if longitude != nil && !(*longitude >= -180 && *longitude <= 180) {
+		return errors.New("Longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// boundingBox returns the latitude and longitude ranges containing every point
+// within radiusKm of the centre. Near the poles or across the antimeridian the
+// longitude range is widened to the whole globe, so the box never misses a point.
+func boundingBox(lat, lng, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat = math.Max(lat-dLat, -90), math.Min(lat+dLat, 90)
+	if minLat == -90 || maxLat == 90 {
+		return minLat, maxLat, -180, 180
+	}
+
+	dLng := math.Asin(math.Min(math.Sin(radiusKm/earthRadiusKm)/math.Cos(lat*math.Pi/180), 1)) * 180 / math.Pi
+	minLng, maxLng = lng-dLng, lng+dLng
+	if minLng < -180 || maxLng > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, minLng, maxLng
+}
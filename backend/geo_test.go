@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"net/url"
+	"testing"
+)
+
+// Test distanza haversine tra due città note
+func TestDistanceKm(t *testing.T) {
+	// Milano - Roma, circa 477 km in linea d'aria
+	d := distanceKm(45.4642, 9.1900, 41.9028, 12.4964)
+	if math.Abs(d-477) > 5 {
+		t.Errorf("Expected about 477 km, got %.1f", d)
+	}
+
+	if d := distanceKm(45.0, 9.0, 45.0, 9.0); d != 0 {
+		t.Errorf("Expected zero distance for the same point, got %f", d)
+	}
+
+	if nullDistanceKm(sql.NullFloat64{}, sql.NullFloat64{Float64: 9, Valid: true}, sql.NullFloat64{Float64: 45, Valid: true}, sql.NullFloat64{Float64: 9, Valid: true}) != nil {
+		t.Error("Expected nil distance when a coordinate is missing")
+	}
+}
+
+// Test bounding box: tutti i punti entro il raggio devono essere inclusi
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		radiusKm float64
+	}{
+		{name: "Milano", lat: 45.4642, lng: 9.1900, radiusKm: 50},
+		{name: "Near the north pole", lat: 89.9, lng: 0, radiusKm: 100},
+		{name: "Across the antimeridian", lat: -17.7, lng: 179.9, radiusKm: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLat, maxLat, minLng, maxLng := boundingBox(tt.lat, tt.lng, tt.radiusKm)
+			for bearing := 0.0; bearing < 360; bearing += 15 {
+				lat, lng := destination(tt.lat, tt.lng, bearing, tt.radiusKm*0.999)
+				if lat < minLat || lat > maxLat || lng < minLng || lng > maxLng {
+					t.Errorf("Point (%.4f, %.4f) at bearing %.0f outside box [%.4f,%.4f]x[%.4f,%.4f]",
+						lat, lng, bearing, minLat, maxLat, minLng, maxLng)
+				}
+			}
+		})
+	}
+}
+
+// Test parametri della ricerca per vicinanza
+func TestParseNearbyQuery(t *testing.T) {
+	params, err := parseNearbyQuery(url.Values{"lat": {"45.46"}, "lng": {"9.19"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.RadiusKm != DefaultNearbyRadiusKm || params.PageLimit != DefaultPageSize {
+		t.Errorf("Unexpected defaults: %+v", params)
+	}
+	if params.MinLat >= 45.46 || params.MaxLat <= 45.46 || params.MinLng >= 9.19 || params.MaxLng <= 9.19 {
+		t.Errorf("Bounding box does not contain the centre: %+v", params)
+	}
+
+	for _, values := range []url.Values{
+		{"lat": {"45.46"}},
+		{"lat": {"abc"}, "lng": {"9.19"}},
+		{"lat": {"91"}, "lng": {"9.19"}},
+		{"lat": {"45"}, "lng": {"NaN"}},
+		{"lat": {"45"}, "lng": {"9"}, "radiusKm": {"0"}},
+		{"lat": {"45"}, "lng": {"9"}, "radiusKm": {"100000"}},
+	} {
+		if _, err := parseNearbyQuery(values); err == nil {
+			t.Errorf("Expected error for %v", values)
+		}
+	}
+}
+
+// destination calcola il punto a distanza d (km) lungo la direzione bearing (gradi)
+func destination(lat, lng, bearing, d float64) (float64, float64) {
+	rad := math.Pi / 180
+	phi1, lambda1, theta, delta := lat*rad, lng*rad, bearing*rad, d/earthRadiusKm
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1), math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+	lng2 := math.Mod(lambda2/rad+540, 360) - 180
+	return phi2 / rad, lng2
+}
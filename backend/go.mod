@@ -1,9 +1,30 @@
 module github.com/marco-introini/conferenze.tech/backend
 
-go 1.25
+go 1.25.0
 
 require github.com/google/uuid v1.6.0
 
 require github.com/lib/pq v1.10.9
 
 require github.com/brianvoe/gofakeit/v7 v7.14.0
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/prometheus/client_golang v1.23.2
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.2 // indirect
+	github.com/prometheus/common v0.66.1 // indirect
+	github.com/prometheus/procfs v0.16.1 // indirect
+	go.yaml.in/yaml/v2 v2.4.2 // indirect
+	google.golang.org/protobuf v1.36.8 // indirect
+)
+
+require (
+	golang.org/x/crypto v0.52.0
+	golang.org/x/sys v0.45.0 // indirect
+)
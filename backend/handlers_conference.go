@@ -5,78 +5,255 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
-// ListConferences retrieves all conferences
+// ListConferences retrieves a page of conferences matching the query parameters:
+// when (upcoming|past), from, to, location, search, sort (date|-date), limit and cursor
 func (s *Server) ListConferences(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	conferences, err := s.db.ListConferences(ctx)
+	params, err := parseConferenceFilters(r.URL.Query())
 	if err != nil {
-		log.Printf("Error listing conferences: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
 
-	response := make([]ConferenceResponse, len(conferences))
+	// One extra row tells whether a next page exists
+	limit := params.PageLimit
+	params.PageLimit++
+
+	conferences, err := s.db.ListConferences(ctx, params)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing conferences", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	response := ConferenceListResponse{Data: make([]ConferenceResponse, 0, len(conferences))}
+	if len(conferences) > int(limit) {
+		conferences = conferences[:limit]
+		last := conferences[len(conferences)-1]
+		next := nextLink(r.URL, encodeCursor(last.Date, last.ID))
+		response.Next = &next
+	}
+	for _, c := range conferences {
+		response.Data = append(response.Data, toConferenceResponse(c))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode conferences response", "error", err)
+	}
+}
+
+// parseConferenceFilters converts the listing query parameters to database parameters.
+// Without a sort parameter upcoming conferences are listed soonest first, all others newest first.
+func parseConferenceFilters(values url.Values) (db.ListConferencesParams, error) {
+	var params db.ListConferencesParams
+
+	switch values.Get("when") {
+	case "":
+	case "upcoming":
+		params.Upcoming = sql.NullBool{Bool: true, Valid: true}
+	case "past":
+		params.Upcoming = sql.NullBool{Bool: false, Valid: true}
+	default:
+		return params, errors.New("When must be upcoming or past")
+	}
+
+	switch values.Get("sort") {
+	case "":
+		params.Descending = !params.Upcoming.Bool
+	case "date":
+		params.Descending = false
+	case "-date":
+		params.Descending = true
+	default:
+		return params, errors.New("Sort must be date or -date")
+	}
+
+	if raw := values.Get("from"); raw != "" {
+		from, err := parseDateParam(raw, false)
+		if err != nil {
+			return params, errors.New("Invalid from date format")
+		}
+		params.FromDate = sql.NullTime{Time: from, Valid: true}
+	}
+	if raw := values.Get("to"); raw != "" {
+		to, err := parseDateParam(raw, true)
+		if err != nil {
+			return params, errors.New("Invalid to date format")
+		}
+		params.ToDate = sql.NullTime{Time: to, Valid: true}
+	}
+
+	if location := strings.TrimSpace(values.Get("location")); location != "" {
+		params.Location = sql.NullString{String: location, Valid: true}
+	}
+	if search := strings.TrimSpace(values.Get("search")); search != "" {
+		params.Search = sql.NullString{String: search, Valid: true}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		date, id, err := decodeCursor(cursor)
+		if err != nil {
+			return params, err
+		}
+		params.CursorDate = sql.NullTime{Time: date, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	limit, err := parseLimit(values)
+	if err != nil {
+		return params, err
+	}
+	params.PageLimit = limit
+
+	return params, nil
+}
+
+// parseDateParam parses an RFC3339 timestamp or a plain date (2006-01-02).
+// A plain date used as an upper bound covers the whole day.
+func parseDateParam(raw string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Microsecond)
+	}
+	return t, nil
+}
+
+// NearbyConferences lists the conferences within radiusKm of a point (lat, lng), closest first
+func (s *Server) NearbyConferences(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	params, err := parseNearbyQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	conferences, err := s.db.ListConferencesNearby(ctx, params)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error searching nearby conferences", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	response := make([]NearbyConferenceResponse, len(conferences))
 	for i, c := range conferences {
-		dateStr := c.Date.Format(time.RFC3339)
-		response[i] = ConferenceResponse{
-			ID:        c.ID.String(),
-			Title:     c.Title,
-			Date:      dateStr,
-			Location:  c.Location,
-			Website:   stringPtr(c.Website),
-			Latitude:  float64Ptr(c.Latitude),
-			Longitude: float64Ptr(c.Longitude),
+		response[i] = NearbyConferenceResponse{
+			ConferenceResponse: toConferenceResponse(db.Conference{
+				ID:        c.ID,
+				Title:     c.Title,
+				Date:      c.Date,
+				Location:  c.Location,
+				Website:   c.Website,
+				Latitude:  c.Latitude,
+				Longitude: c.Longitude,
+				CreatedBy: c.CreatedBy,
+				CreatedAt: c.CreatedAt,
+				UpdatedAt: c.UpdatedAt,
+				Capacity:  c.Capacity,
+			}),
+			DistanceKm: c.DistanceKm,
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Failed to encode conferences response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode nearby conferences response", "error", err)
 	}
 }
 
+// parseNearbyQuery converts the lat, lng, radiusKm and limit query parameters to database parameters
+func parseNearbyQuery(values url.Values) (db.ListConferencesNearbyParams, error) {
+	var params db.ListConferencesNearbyParams
+
+	if values.Get("lat") == "" || values.Get("lng") == "" {
+		return params, errors.New("Lat and lng are required")
+	}
+	lat, err := strconv.ParseFloat(values.Get("lat"), 64)
+	if err != nil {
+		return params, errors.New("Invalid lat")
+	}
+	lng, err := strconv.ParseFloat(values.Get("lng"), 64)
+	if err != nil {
+		return params, errors.New("Invalid lng")
+	}
+	if err := validateCoordinates(&lat, &lng); err != nil {
+		return params, err
+	}
+
+	radius := DefaultNearbyRadiusKm
+	if raw := values.Get("radiusKm"); raw != "" {
+		radius, err = strconv.ParseFloat(raw, 64)
+		if err != nil || !(radius > 0 && radius <= MaxNearbyRadiusKm) {
+			return params, fmt.Errorf("RadiusKm must be greater than 0 and at most %g", MaxNearbyRadiusKm)
+		}
+	}
+
+	limit, err := parseLimit(values)
+	if err != nil {
+		return params, err
+	}
+
+	params.Lat, params.Lng, params.RadiusKm, params.PageLimit = lat, lng, radius, limit
+	params.MinLat, params.MaxLat, params.MinLng, params.MaxLng = boundingBox(lat, lng, radius)
+	return params, nil
+}
+
 // GetConference retrieves a specific conference with its attendees
 func (s *Server) GetConference(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
 	idStr := r.PathValue("conference_id")
 	if idStr == "" {
-		http.Error(w, "Conference ID required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Conference ID required")
 		return
 	}
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "Invalid conference ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
 		return
 	}
 
 	conference, err := s.db.GetConferenceByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Conference not found", http.StatusNotFound)
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
 			return
 		}
-		log.Printf("Error getting conference: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting conference", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
-	registrations, err := s.db.GetRegistrationsByConference(ctx, id)
+	registrations, err := s.db.GetRegistrationsByConference(ctx, db.GetRegistrationsByConferenceParams{
+		ConferenceID: id,
+	})
 	if err != nil {
-		log.Printf("Error getting registrations: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting registrations", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
@@ -88,6 +265,7 @@ func (s *Server) GetConference(w http.ResponseWriter, r *http.Request) {
 		Website:   stringPtr(conference.Website),
 		Latitude:  float64Ptr(conference.Latitude),
 		Longitude: float64Ptr(conference.Longitude),
+		Capacity:  int32Ptr(conference.Capacity),
 		Attendees: make([]Attendee, 0),
 	}
 
@@ -101,6 +279,7 @@ func (s *Server) GetConference(w http.ResponseWriter, r *http.Request) {
 				City:      stringPtr(reg.City),
 				AvatarURL: stringPtr(reg.AvatarUrl),
 			},
+			Status:    reg.Status,
 			NeedsRide: boolPtr(reg.NeedsRide),
 			HasCar:    boolPtr(reg.HasCar),
 		}
@@ -108,38 +287,78 @@ func (s *Server) GetConference(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(conference.UpdatedAt))
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Failed to encode conference response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode conference response", "error", err)
+	}
+}
+
+// GetConferenceStats retrieves registration and carpooling figures for a conference
+func (s *Server) GetConferenceStats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	id, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	stats, err := s.db.GetConferenceStats(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error getting conference stats", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	response := ConferenceStatsResponse{
+		ConferenceID:       stats.ID.String(),
+		Title:              stats.Title,
+		Capacity:           int32Ptr(stats.Capacity),
+		TotalRegistrations: stats.TotalRegistrations,
+		ConfirmedCount:     stats.ConfirmedCount,
+		WaitlistCount:      stats.WaitlistCount,
+		CancelledCount:     stats.CancelledCount,
+		NeedingRideCount:   stats.NeedingRideCount,
+		OfferingRideCount:  stats.OfferingRideCount,
+	}
+	if stats.Capacity.Valid {
+		available := max(int64(stats.Capacity.Int32)-stats.ConfirmedCount, 0)
+		response.AvailableSeats = &available
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode conference stats response", "error", err)
 	}
 }
 
 // CreateConference creates a new conference
 func (s *Server) CreateConference(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusNotFound)
 		return
 	}
 
 	var req CreateConferenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
-	if req.Title == "" || req.Location == "" || req.Date == "" {
-		http.Error(w, "Title, location and date are required", http.StatusBadRequest)
-		return
-	}
-
-	date, err := time.Parse(time.RFC3339, req.Date)
-	if err != nil {
-		http.Error(w, "Invalid date format", http.StatusBadRequest)
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
 		return
 	}
+	// The format was checked by validateRequest
+	date, _ := time.Parse(time.RFC3339, req.Date)
 
 	conference, err := s.db.CreateConference(ctx, db.CreateConferenceParams{
 		Title:     req.Title,
@@ -149,73 +368,355 @@ func (s *Server) CreateConference(w http.ResponseWriter, r *http.Request) {
 		Latitude:  nullFloat64(req.Latitude),
 		Longitude: nullFloat64(req.Longitude),
 		CreatedBy: userID,
+		Capacity:  nullInt32(req.Capacity),
 	})
 	if err != nil {
-		log.Printf("Error creating conference: %v", err)
-		http.Error(w, "Failed to create conference", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error creating conference", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to create conference")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(conference.UpdatedAt))
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(ConferenceResponse{
-		ID:        conference.ID.String(),
-		Title:     conference.Title,
-		Date:      conference.Date.Format(time.RFC3339),
-		Location:  conference.Location,
-		Website:   stringPtr(conference.Website),
-		Latitude:  float64Ptr(conference.Latitude),
-		Longitude: float64Ptr(conference.Longitude),
-		CreatedBy: userID.String(),
-	}); err != nil {
-		log.Printf("Failed to encode create conference response: %v", err)
+	if err := json.NewEncoder(w).Encode(toConferenceResponse(conference)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode create conference response", "error", err)
+	}
+}
+
+// errConferenceModified is returned from the update transaction when If-Match is stale
+var errConferenceModified = errors.New("conference modified")
+
+// updateConferenceParams maps an update request to the query parameters:
+// omitted fields keep their value, the clear flags set the column to NULL
+func updateConferenceParams(id uuid.UUID, req UpdateConferenceRequest, ifUpdatedAt *time.Time) db.UpdateConferenceParams {
+	var date *time.Time
+	if req.Date != nil {
+		// The format was checked by validateRequest
+		d, _ := time.Parse(time.RFC3339, *req.Date)
+		date = &d
+	}
+
+	return db.UpdateConferenceParams{
+		ID:               id,
+		Title:            nullString(req.Title),
+		Date:             nullTime(date),
+		Location:         nullString(req.Location),
+		ClearWebsite:     req.ClearWebsite,
+		Website:          nullString(req.Website),
+		ClearCoordinates: req.ClearCoordinates,
+		Latitude:         nullFloat64(req.Latitude),
+		Longitude:        nullFloat64(req.Longitude),
+		ClearCapacity:    req.ClearCapacity,
+		Capacity:         nullInt32(req.Capacity),
+		IfUpdatedAt:      nullTime(ifUpdatedAt),
+	}
+}
+
+// UpdateConference partially updates a conference.
+// The If-Match header must carry the ETag returned by GetConference (or "*")
+// so that concurrent edits are detected instead of silently overwritten.
+func (s *Server) UpdateConference(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	if !s.authorizeConference(ctx, w, userID, id, ActionUpdateConference) {
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		writeError(w, http.StatusPreconditionRequired, CodePreconditionRequired, "If-Match header required")
+		return
+	}
+	var ifUpdatedAt *time.Time
+	if ifMatch != "*" {
+		t, ok := parseETag(ifMatch)
+		if !ok {
+			writeError(w, http.StatusPreconditionFailed, CodePreconditionFailed, "Invalid If-Match header")
+			return
+		}
+		ifUpdatedAt = &t
+	}
+
+	var req UpdateConferenceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	var conference db.Conference
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		// No rows here means the conference was deleted after the authorization check
+		if _, err := q.LockConferenceCapacity(ctx, id); err != nil {
+			return err
+		}
+
+		conference, err = q.UpdateConference(ctx, updateConferenceParams(id, req, ifUpdatedAt))
+		if errors.Is(err, sql.ErrNoRows) {
+			// The row is locked and exists, so only the If-Match check can fail
+			return errConferenceModified
+		}
+		if err != nil {
+			return err
+		}
+
+		// A larger or removed capacity frees seats for waitlisted users
+		if req.Capacity != nil || req.ClearCapacity {
+			return promoteWaitlist(ctx, q, id, conference.Capacity)
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
+		case errors.Is(err, errConferenceModified):
+			writeError(w, http.StatusPreconditionFailed, CodeConferenceModified, "Conference was modified by someone else")
+		default:
+			slog.ErrorContext(r.Context(), "Error updating conference", "error", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to update conference")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(conference.UpdatedAt))
+	if err := json.NewEncoder(w).Encode(toConferenceResponse(conference)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode update conference response", "error", err)
 	}
 }
 
 // DeleteConference deletes a conference
 func (s *Server) DeleteConference(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusNotFound)
 		return
 	}
 
 	idStr := r.PathValue("conference_id")
 	if idStr == "" {
-		http.Error(w, "Conference ID required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Conference ID required")
 		return
 	}
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "Invalid conference ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
 		return
 	}
 
-	// Check if the user is the one who created the conference
-	conference, err := s.db.GetConferenceByID(ctx, id)
+	if !s.authorizeConference(ctx, w, userID, id, ActionDeleteConference) {
+		return
+	}
+
+	err = s.db.DeleteConference(ctx, id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting conference", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to delete conference")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListConferenceRegistrations retrieves all registrations of a conference for its organizers,
+// including cancelled ones so that drop-outs remain visible
+func (s *Server) ListConferenceRegistrations(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	if !s.authorizeConference(ctx, w, userID, id, ActionViewAttendees) {
+		return
+	}
+
+	registrations, err := s.db.GetRegistrationsByConference(ctx, db.GetRegistrationsByConferenceParams{
+		ConferenceID:     id,
+		IncludeCancelled: true,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting registrations", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	response := make([]ConferenceRegistrationResponse, len(registrations))
+	for i, reg := range registrations {
+		response[i] = ConferenceRegistrationResponse{
+			ID: reg.ID.String(),
+			User: UserResponse{
+				ID:        reg.UserID.String(),
+				Email:     reg.Email,
+				Name:      reg.Name,
+				Nickname:  stringPtr(reg.Nickname),
+				City:      stringPtr(reg.City),
+				AvatarURL: stringPtr(reg.AvatarUrl),
+			},
+			Status:       reg.Status,
+			Role:         reg.Role,
+			Notes:        stringPtr(reg.Notes),
+			NeedsRide:    boolPtr(reg.NeedsRide),
+			HasCar:       boolPtr(reg.HasCar),
+			RegisteredAt: *timePtr(reg.RegisteredAt),
+			CancelledAt:  timePtr(reg.CancelledAt),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode conference registrations response", "error", err)
+	}
+}
+
+// AddOrganizer grants the organizer role on a conference to a user
+func (s *Server) AddOrganizer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conferenceID, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	organizerID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidUserID, "Invalid user ID")
+		return
+	}
+
+	if !s.authorizeConference(ctx, w, userID, conferenceID, ActionManageOrganizers) {
+		return
+	}
+
+	organizer, err := s.db.GetUserByID(ctx, organizerID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Conference not found", http.StatusNotFound)
+			writeError(w, http.StatusNotFound, CodeUserNotFound, "User not found")
 			return
 		}
-		log.Printf("Error checking conference ownership: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
-	if conference.CreatedBy != userID {
-		http.Error(w, "User not authorized to delete this conference", http.StatusForbidden)
+	// Like RegisterToConference, a user who is not already attending takes a seat
+	// only if one is free, under the conference row lock
+	var registration db.ConferenceRegistration
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		capacity, err := q.LockConferenceCapacity(ctx, conferenceID)
+		if err != nil {
+			return err
+		}
+		confirmed, err := q.CountConfirmedRegistrations(ctx, conferenceID)
+		if err != nil {
+			return err
+		}
+
+		registration, err = q.UpsertConferenceOrganizer(ctx, db.UpsertConferenceOrganizerParams{
+			UserID:       organizerID,
+			ConferenceID: conferenceID,
+			Status:       registrationStatus(capacity, confirmed),
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error adding organizer", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to add organizer")
 		return
 	}
 
-	err = s.db.DeleteConference(ctx, id)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ConferenceRegistrationResponse{
+		ID:           registration.ID.String(),
+		User:         toUserResponse(organizer),
+		Status:       registration.Status,
+		Role:         registration.Role,
+		Notes:        stringPtr(registration.Notes),
+		NeedsRide:    boolPtr(registration.NeedsRide),
+		HasCar:       boolPtr(registration.HasCar),
+		RegisteredAt: *timePtr(registration.RegisteredAt),
+	}); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode organizer response", "error", err)
+	}
+}
+
+// RemoveOrganizer revokes the organizer role on a conference, keeping the user registered as attendee
+func (s *Server) RemoveOrganizer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conferenceID, err := uuid.Parse(r.PathValue("conference_id"))
 	if err != nil {
-		log.Printf("Error deleting conference: %v", err)
-		http.Error(w, "Failed to delete conference", http.StatusInternalServerError)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	organizerID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidUserID, "Invalid user ID")
+		return
+	}
+
+	if !s.authorizeConference(ctx, w, userID, conferenceID, ActionManageOrganizers) {
+		return
+	}
+
+	_, err = s.db.RemoveConferenceOrganizer(ctx, db.RemoveConferenceOrganizerParams{
+		UserID:       organizerID,
+		ConferenceID: conferenceID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, CodeOrganizerNotFound, "Organizer not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error removing organizer", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to remove organizer")
 		return
 	}
 
@@ -3,12 +3,17 @@ package main
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
 // Test validazione JSON per CreateConferenceRequest
@@ -243,6 +248,235 @@ func TestRegisterToConferenceRequest(t *testing.T) {
 	}
 }
 
+// Test validazione website e coordinate
+func TestValidateConferenceDetails(t *testing.T) {
+	website := func(s string) *string { return &s }
+	coord := func(f float64) *float64 { return &f }
+	capacity := func(i int32) *int32 { return &i }
+
+	tests := []struct {
+		name        string
+		website     *string
+		latitude    *float64
+		longitude   *float64
+		capacity    *int32
+		shouldError bool
+	}{
+		{name: "All empty", shouldError: false},
+		{name: "Valid values", website: website("https://gophercon.it"), latitude: coord(45.46), longitude: coord(9.19), shouldError: false},
+		{name: "Relative URL", website: website("gophercon.it"), shouldError: true},
+		{name: "Unsupported scheme", website: website("ftp://gophercon.it"), shouldError: true},
+		{name: "Latitude out of range", latitude: coord(91), shouldError: true},
+		{name: "Longitude out of range", latitude: coord(0), longitude: coord(-180.5), shouldError: true},
+		{name: "Latitude without longitude", latitude: coord(45.46), shouldError: true},
+		{name: "Positive capacity", capacity: capacity(100), shouldError: false},
+		{name: "Zero capacity", capacity: capacity(0), shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateRequest(UpdateConferenceRequest{
+				Website:   tt.website,
+				Latitude:  tt.latitude,
+				Longitude: tt.longitude,
+				Capacity:  tt.capacity,
+			})
+			if tt.shouldError && len(errs) == 0 {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.shouldError && len(errs) > 0 {
+				t.Errorf("Expected no error but got: %v", errs)
+			}
+		})
+	}
+}
+
+// Test rimozione dei campi opzionali in aggiornamento: i flag clear* azzerano la colonna
+func TestUpdateConferenceParams(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name   string
+		body   string
+		errors map[string]string
+		check  func(p db.UpdateConferenceParams) bool
+	}{
+		{
+			name:  "Clear capacity",
+			body:  `{"clearCapacity": true}`,
+			check: func(p db.UpdateConferenceParams) bool { return p.ClearCapacity && !p.Capacity.Valid && !p.ClearWebsite },
+		},
+		{
+			name: "Clear website and coordinates",
+			body: `{"clearWebsite": true, "clearCoordinates": true, "title": "GoLab"}`,
+			check: func(p db.UpdateConferenceParams) bool {
+				return p.ClearWebsite && p.ClearCoordinates && !p.ClearCapacity && p.Title.String == "GoLab"
+			},
+		},
+		{
+			name:  "Omitted fields are kept",
+			body:  `{"capacity": 50}`,
+			check: func(p db.UpdateConferenceParams) bool { return !p.ClearCapacity && p.Capacity.Int32 == 50 },
+		},
+		{
+			name:   "Capacity both set and cleared",
+			body:   `{"capacity": 50, "clearCapacity": true}`,
+			errors: map[string]string{"clearCapacity": FieldNotAllowed},
+		},
+		{
+			name:   "Coordinates both set and cleared",
+			body:   `{"latitude": 45.46, "longitude": 9.19, "clearCoordinates": true}`,
+			errors: map[string]string{"clearCoordinates": FieldNotAllowed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/conferences/"+id.String(), strings.NewReader(tt.body))
+			var update UpdateConferenceRequest
+			if err := decodeJSON(req, &update); err != nil {
+				t.Fatalf("Failed to decode body: %v", err)
+			}
+
+			errs := validateRequest(update)
+			got := make(map[string]string, len(errs))
+			for _, fe := range errs {
+				got[fe.Field] = fe.Code
+			}
+			if len(got) != len(tt.errors) {
+				t.Fatalf("Expected errors %v, got %v", tt.errors, got)
+			}
+			for field, code := range tt.errors {
+				if got[field] != code {
+					t.Errorf("Expected %s on %s, got %q", code, field, got[field])
+				}
+			}
+			if tt.check == nil {
+				return
+			}
+
+			params := updateConferenceParams(id, update, nil)
+			if params.ID != id || params.IfUpdatedAt.Valid {
+				t.Errorf("Unexpected ID or If-Match in %+v", params)
+			}
+			if !tt.check(params) {
+				t.Errorf("Unexpected parameters %+v", params)
+			}
+		})
+	}
+}
+
+// Test ETag basato su updated_at
+func TestConferenceETagRoundtrip(t *testing.T) {
+	updatedAt := sql.NullTime{Time: time.Date(2026, 9, 15, 10, 30, 0, 123456000, time.UTC), Valid: true}
+
+	tag := etag(updatedAt)
+	parsed, ok := parseETag(tag)
+	if !ok {
+		t.Fatalf("Failed to parse ETag %s", tag)
+	}
+	if !parsed.Equal(updatedAt.Time) {
+		t.Errorf("Expected %v, got %v", updatedAt.Time, parsed)
+	}
+
+	if _, ok := parseETag("W/" + tag); !ok {
+		t.Error("Expected weak ETag to be accepted")
+	}
+	for _, invalid := range []string{"", "abc", `"abc"`, `"123`} {
+		if _, ok := parseETag(invalid); ok {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+// Test stato della registrazione in base alla capienza
+func TestRegistrationStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		capacity  sql.NullInt32
+		confirmed int64
+		want      string
+	}{
+		{name: "Unlimited capacity", capacity: sql.NullInt32{}, confirmed: 1000, want: StatusRegistered},
+		{name: "Seats available", capacity: sql.NullInt32{Int32: 10, Valid: true}, confirmed: 9, want: StatusRegistered},
+		{name: "Conference full", capacity: sql.NullInt32{Int32: 10, Valid: true}, confirmed: 10, want: StatusWaitlist},
+		{name: "Over capacity", capacity: sql.NullInt32{Int32: 5, Valid: true}, confirmed: 7, want: StatusWaitlist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registrationStatus(tt.capacity, tt.confirmed); got != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// Test parsing dei filtri della lista conferenze
+func TestParseConferenceFilters(t *testing.T) {
+	params, err := parseConferenceFilters(url.Values{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !params.Descending || params.Upcoming.Valid || params.PageLimit != DefaultPageSize {
+		t.Errorf("Unexpected defaults: %+v", params)
+	}
+
+	params, err = parseConferenceFilters(url.Values{
+		"when":     {"upcoming"},
+		"to":       {"2026-06-30"},
+		"location": {" Milano "},
+		"search":   {"golang"},
+		"limit":    {"5"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !params.Upcoming.Valid || !params.Upcoming.Bool || params.Descending {
+		t.Error("Expected upcoming conferences sorted by ascending date")
+	}
+	if want := time.Date(2026, 6, 30, 23, 59, 59, 999999000, time.UTC); !params.ToDate.Time.Equal(want) {
+		t.Errorf("Expected to date %v, got %v", want, params.ToDate.Time)
+	}
+	if params.Location.String != "Milano" || params.Search.String != "golang" || params.PageLimit != 5 {
+		t.Errorf("Unexpected filters: %+v", params)
+	}
+
+	for _, values := range []url.Values{
+		{"when": {"tomorrow"}},
+		{"sort": {"title"}},
+		{"from": {"yesterday"}},
+		{"limit": {"0"}},
+		{"limit": {"1000"}},
+		{"cursor": {"not-a-cursor"}},
+	} {
+		if _, err := parseConferenceFilters(values); err == nil {
+			t.Errorf("Expected error for %v", values)
+		}
+	}
+}
+
+// Test cursore di paginazione e link alla pagina successiva
+func TestConferenceCursor(t *testing.T) {
+	date := time.Date(2026, 3, 14, 9, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	cursor := encodeCursor(date, id)
+	params, err := parseConferenceFilters(url.Values{"cursor": {cursor}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !params.CursorDate.Time.Equal(date) || params.CursorID.UUID != id {
+		t.Errorf("Cursor roundtrip failed: got %v %v", params.CursorDate.Time, params.CursorID.UUID)
+	}
+
+	u, _ := url.Parse("/api/conferences?search=go&cursor=old")
+	next, _ := url.Parse(nextLink(u, cursor))
+	if next.Path != "/api/conferences" || next.Query().Get("search") != "go" || next.Query().Get("cursor") != cursor {
+		t.Errorf("Unexpected next link: %s", next)
+	}
+}
+
 // Helper functions per test
 func newAuthRequest(method, url string, body []byte, userID uuid.UUID) *http.Request {
 	req := httptest.NewRequest(method, url, bytes.NewBuffer(body))
@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -13,96 +13,164 @@ import (
 	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
+// Sentinel errors returned from registration transactions
+var (
+	errAlreadyRegistered    = errors.New("user already registered")
+	errRegistrationNotFound = errors.New("registration not found")
+)
+
+// registrationStatus decides whether a new registration is confirmed or waitlisted
+func registrationStatus(capacity sql.NullInt32, confirmed int64) string {
+	if capacity.Valid && confirmed >= int64(capacity.Int32) {
+		return StatusWaitlist
+	}
+	return StatusRegistered
+}
+
+// promoteWaitlist confirms the oldest waitlisted registrations while seats are available.
+// The caller must hold the conference row lock (LockConferenceCapacity) in the same transaction.
+func promoteWaitlist(ctx context.Context, q db.Querier, conferenceID uuid.UUID, capacity sql.NullInt32) error {
+	confirmed, err := q.CountConfirmedRegistrations(ctx, conferenceID)
+	if err != nil {
+		return err
+	}
+
+	for !capacity.Valid || confirmed < int64(capacity.Int32) {
+		registration, err := q.PromoteNextWaitlisted(ctx, conferenceID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Promoted user from waitlist", "promoted_user_id", registration.UserID, "conference_id", conferenceID)
+		confirmed++
+	}
+	return nil
+}
+
 // RegisterToConference handles user registration to a conference
 func (s *Server) RegisterToConference(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
 	var req RegisterToConferenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
 		return
 	}
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusNotFound)
 		return
 	}
 
 	idStr := r.PathValue("conference_id")
 	conferenceID, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "Invalid conference ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
 		return
 	}
 
-	_, err = s.db.GetConferenceByID(ctx, conferenceID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Conference not found", http.StatusNotFound)
-			return
-		}
-		log.Printf("Error getting conference: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	role := req.Role
+	if role == "" {
+		role = RoleAttendee
 	}
 
-	_, err = s.db.GetRegistration(ctx, db.GetRegistrationParams{
-		UserID:       userID,
-		ConferenceID: conferenceID,
-	})
-	if err == nil {
-		http.Error(w, "User already registered to this conference", http.StatusConflict)
-		return
-	}
-	if !errors.Is(err, sql.ErrNoRows) {
-		log.Printf("Error checking existing registration: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	// Only users allowed to manage organizers can register someone as organizer
+	if role == RoleOrganizer && !s.authorizeConference(ctx, w, userID, conferenceID, ActionManageOrganizers) {
 		return
 	}
 
-	role := req.Role
-	if !IsValidRole(role) {
-		role = RoleAttendee
-	}
+	// The conference row is locked for the whole transaction, so concurrent
+	// registrations cannot both take the last seat.
+	var registration db.ConferenceRegistration
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		capacity, err := q.LockConferenceCapacity(ctx, conferenceID)
+		if err != nil {
+			return err
+		}
 
-	registration, err := s.db.RegisterUserToConference(ctx, db.RegisterUserToConferenceParams{
-		UserID:       userID,
-		ConferenceID: conferenceID,
-		Role:         role,
-		Notes:        nullString(req.Notes),
-		NeedsRide:    nullBool(req.NeedsRide),
-		HasCar:       nullBool(req.HasCar),
+		existing, err := q.GetRegistration(ctx, db.GetRegistrationParams{
+			UserID:       userID,
+			ConferenceID: conferenceID,
+		})
+		found := err == nil
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if found && existing.Status != StatusCancelled {
+			return errAlreadyRegistered
+		}
+
+		confirmed, err := q.CountConfirmedRegistrations(ctx, conferenceID)
+		if err != nil {
+			return err
+		}
+		status := registrationStatus(capacity, confirmed)
+
+		// A previous cancellation is reactivated instead of inserting a duplicate row
+		if found {
+			registration, err = q.ReactivateRegistration(ctx, db.ReactivateRegistrationParams{
+				ID:        existing.ID,
+				Status:    status,
+				Role:      role,
+				Notes:     nullString(req.Notes),
+				NeedsRide: nullBool(req.NeedsRide),
+				HasCar:    nullBool(req.HasCar),
+			})
+			return err
+		}
+
+		registration, err = q.RegisterUserToConference(ctx, db.RegisterUserToConferenceParams{
+			UserID:       userID,
+			ConferenceID: conferenceID,
+			Role:         role,
+			Notes:        nullString(req.Notes),
+			NeedsRide:    nullBool(req.NeedsRide),
+			HasCar:       nullBool(req.HasCar),
+			Status:       status,
+		})
+		return err
 	})
 	if err != nil {
-		log.Printf("Error registering user: %v", err)
-		http.Error(w, "Failed to register to conference", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
+		case errors.Is(err, errAlreadyRegistered):
+			writeError(w, http.StatusConflict, CodeAlreadyRegistered, "User already registered to this conference")
+		default:
+			slog.ErrorContext(r.Context(), "Error registering user", "error", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to register to conference")
+		}
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(registration); err != nil {
-		log.Printf("Failed to encode registration response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode registration response", "error", err)
 	}
 }
 
 // GetUserRegistrations retrieves all registrations for a specific user
 func (s *Server) GetUserRegistrations(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusNotFound)
 		return
 	}
 
 	registrations, err := s.db.GetRegistrationsByUser(ctx, userID)
 	if err != nil {
-		log.Printf("Error getting registrations: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting registrations", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
@@ -124,62 +192,71 @@ func (s *Server) GetUserRegistrations(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Failed to encode registrations response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode registrations response", "error", err)
 	}
 }
 
-// UnregisterFromConference removes a user's registration from a conference
+// UnregisterFromConference cancels a user's registration to a conference,
+// together with the user's ride offer and ride request for it
 func (s *Server) UnregisterFromConference(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusNotFound)
 		return
 	}
 
 	idStr := r.PathValue("conference_id")
 	conferenceID, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "Invalid conference ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
 		return
 	}
 
-	_, err = s.db.GetConferenceByID(ctx, conferenceID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Conference not found", http.StatusNotFound)
-			return
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		capacity, err := q.LockConferenceCapacity(ctx, conferenceID)
+		if err != nil {
+			return err
 		}
-		log.Printf("Error getting conference: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
 
-	// Check if registration exists
-	_, err = s.db.GetRegistration(ctx, db.GetRegistrationParams{
-		UserID:       userID,
-		ConferenceID: conferenceID,
-	})
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Registration not found", http.StatusNotFound)
-			return
+		// Check if an active registration exists
+		registration, err := q.GetRegistration(ctx, db.GetRegistrationParams{
+			UserID:       userID,
+			ConferenceID: conferenceID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errRegistrationNotFound
+			}
+			return err
+		}
+		if registration.Status == StatusCancelled {
+			return errRegistrationNotFound
+		}
+
+		// Soft cancel: the row is kept to preserve the registration history
+		if _, err := q.CancelRegistration(ctx, registration.ID); err != nil {
+			return err
 		}
-		log.Printf("Error checking registration: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
 
-	// Delete registration
-	err = s.db.DeleteRegistration(ctx, db.DeleteRegistrationParams{
-		UserID:       userID,
-		ConferenceID: conferenceID,
+		// Users no longer attending cannot drive or ride to the conference
+		if err := cancelUserRides(ctx, q, userID, conferenceID); err != nil {
+			return err
+		}
+
+		return promoteWaitlist(ctx, q, conferenceID, capacity)
 	})
 	if err != nil {
-		log.Printf("Error deleting registration: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, CodeConferenceNotFound, "Conference not found")
+		case errors.Is(err, errRegistrationNotFound):
+			writeError(w, http.StatusNotFound, CodeRegistrationNotFound, "Registration not found")
+		default:
+			slog.ErrorContext(r.Context(), "Error cancelling registration", "error", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		}
 		return
 	}
 
@@ -0,0 +1,883 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// Sentinel errors returned from carpooling transactions
+var (
+	errRideOfferNotFound   = errors.New("ride offer not found")
+	errRideRequestNotFound = errors.New("ride request not found")
+	errRideForbidden       = errors.New("ride belongs to another user")
+	errRideInvalidState    = errors.New("ride request is not in a valid state for this action")
+	errNoSeatsAvailable    = errors.New("no seats available")
+	errNotRegistered       = errors.New("user not registered to the conference")
+	errRideAlreadyExists   = errors.New("ride already exists")
+	errRideDeclined        = errors.New("ride offer declined the passenger")
+)
+
+// writeRideError maps carpooling errors to HTTP responses
+func writeRideError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	switch {
+	case errors.Is(err, errRideOfferNotFound):
+		writeError(w, http.StatusNotFound, CodeRideOfferNotFound, "Ride offer not found")
+	case errors.Is(err, errRideRequestNotFound):
+		writeError(w, http.StatusNotFound, CodeRideRequestNotFound, "Ride request not found")
+	case errors.Is(err, errRideForbidden):
+		writeError(w, http.StatusForbidden, CodeForbidden, "User not authorized to perform this action")
+	case errors.Is(err, errNotRegistered):
+		writeError(w, http.StatusForbidden, CodeRegistrationRequired, "User must be registered to the conference")
+	case errors.Is(err, errRideInvalidState):
+		writeError(w, http.StatusConflict, CodeRideRequestInvalidState, "Ride request is not in a valid state for this action")
+	case errors.Is(err, errNoSeatsAvailable):
+		writeError(w, http.StatusConflict, CodeRideFull, "No seats available on this ride")
+	case errors.Is(err, errRideAlreadyExists):
+		writeError(w, http.StatusConflict, CodeRideOfferExists, "Ride already exists for this conference")
+	case errors.Is(err, errRideDeclined):
+		writeError(w, http.StatusConflict, CodeRideDeclined, "The driver already declined this passenger")
+	default:
+		slog.ErrorContext(r.Context(), "Error "+action, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+	}
+}
+
+// getRideOffer returns the ride offer with the given ID, or errRideOfferNotFound
+func getRideOffer(ctx context.Context, q db.Querier, id uuid.UUID) (db.RideOffer, error) {
+	offer, err := q.GetRideOffer(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return offer, errRideOfferNotFound
+	}
+	return offer, err
+}
+
+// lockRideRequest locks the ride request with the given ID for the rest of the
+// transaction, or returns errRideRequestNotFound
+func lockRideRequest(ctx context.Context, q db.Querier, id uuid.UUID) (db.RideRequest, error) {
+	request, err := q.LockRideRequest(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return request, errRideRequestNotFound
+	}
+	return request, err
+}
+
+// requireRegistration checks that the user has an active registration to the conference
+func requireRegistration(ctx context.Context, q db.Querier, userID, conferenceID uuid.UUID) error {
+	registration, err := q.GetRegistration(ctx, db.GetRegistrationParams{
+		UserID:       userID,
+		ConferenceID: conferenceID,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && registration.Status == StatusCancelled) {
+		return errNotRegistered
+	}
+	return err
+}
+
+// setNeedsRide updates the needs_ride flag of a passenger's registration
+func setNeedsRide(ctx context.Context, q db.Querier, userID, conferenceID uuid.UUID, needsRide bool) error {
+	return q.SetRegistrationRideFlags(ctx, db.SetRegistrationRideFlagsParams{
+		NeedsRide:    sql.NullBool{Bool: needsRide, Valid: true},
+		UserID:       userID,
+		ConferenceID: conferenceID,
+	})
+}
+
+// sameCity compares two city names ignoring case and surrounding spaces
+func sameCity(a, b string) bool {
+	return a != "" && strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// rankRideMatches returns the offers a passenger can join, best matches first:
+// offers leaving from the passenger's city, then the closest ones, then the earliest departures.
+// The passenger's own offer, full offers and offers that declined the passenger are excluded.
+func rankRideMatches(request db.RideRequest, offers []db.ListRideOffersByConferenceRow, declined []uuid.UUID) []RideMatchResponse {
+	matches := make([]RideMatchResponse, 0, len(offers))
+	departures := make(map[string]time.Time, len(offers))
+	for _, o := range offers {
+		if o.DriverID == request.PassengerID || o.SeatsTaken >= o.Seats || slices.Contains(declined, o.ID) {
+			continue
+		}
+		match := RideMatchResponse{
+			Offer:      toRideOfferRowResponse(o),
+			SameCity:   sameCity(request.PickupCity.String, o.DepartureCity),
+			DistanceKm: nullDistanceKm(request.PickupLatitude, request.PickupLongitude, o.DepartureLatitude, o.DepartureLongitude),
+		}
+		departures[match.Offer.ID] = o.DepartureTime
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.SameCity != b.SameCity {
+			return a.SameCity
+		}
+		if (a.DistanceKm == nil) != (b.DistanceKm == nil) {
+			return a.DistanceKm != nil
+		}
+		if a.DistanceKm != nil && *a.DistanceKm != *b.DistanceKm {
+			return *a.DistanceKm < *b.DistanceKm
+		}
+		return departures[a.Offer.ID].Before(departures[b.Offer.ID])
+	})
+	return matches
+}
+
+// GetConferenceRides returns the ride offers of a conference together with the
+// attendees that flagged they need or offer a ride. Only attendees and the users
+// who can see the attendee list may read it.
+func (s *Server) GetConferenceRides(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conferenceID, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	if err := requireRegistration(ctx, s.db, userID, conferenceID); err != nil {
+		if !errors.Is(err, errNotRegistered) {
+			writeRideError(w, r, err, "checking registration")
+			return
+		}
+		if !s.authorizeConference(ctx, w, userID, conferenceID, ActionViewAttendees) {
+			return
+		}
+	}
+
+	// The driver of the caller's accepted ride is shown with the email address
+	var acceptedOffer uuid.UUID
+	request, err := s.db.GetRideRequestByPassenger(ctx, db.GetRideRequestByPassengerParams{
+		ConferenceID: conferenceID,
+		PassengerID:  userID,
+	})
+	switch {
+	case err == nil && request.Status == RideAccepted && request.OfferID.Valid:
+		acceptedOffer = request.OfferID.UUID
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		slog.ErrorContext(r.Context(), "Error getting ride request", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	offers, err := s.db.ListRideOffersByConference(ctx, conferenceID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing ride offers", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+	needing, err := s.db.ListUsersNeedingRide(ctx, conferenceID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing users needing a ride", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+	offering, err := s.db.ListUsersOfferingRide(ctx, conferenceID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing users offering a ride", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	response := RidesOverviewResponse{
+		Offers:       make([]RideOfferResponse, len(offers)),
+		NeedingRide:  make([]RideUser, len(needing)),
+		OfferingRide: make([]RideUser, len(offering)),
+	}
+	for i, o := range offers {
+		response.Offers[i] = toRideOfferRowResponse(o)
+		if o.ID == acceptedOffer {
+			response.Offers[i].Driver.Email = &o.Email
+		}
+	}
+	for i, u := range needing {
+		response.NeedingRide[i] = RideUser{
+			User:  rideUserResponse(u.ID, u.Name, u.Nickname, u.City),
+			Notes: stringPtr(u.Notes),
+		}
+	}
+	for i, u := range offering {
+		response.OfferingRide[i] = RideUser{
+			User:  rideUserResponse(u.ID, u.Name, u.Nickname, u.City),
+			Notes: stringPtr(u.Notes),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode rides response", "error", err)
+	}
+}
+
+// CreateRideOffer publishes the authenticated user's car for a conference
+func (s *Server) CreateRideOffer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conferenceID, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	var req CreateRideOfferRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	// The format was checked by validateRequest
+	departureTime, _ := time.Parse(time.RFC3339, req.DepartureTime)
+
+	city, err := s.cityOrDefault(ctx, userID, req.DepartureCity)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+	if city == "" {
+		writeValidationError(w, []FieldError{{Field: "departureCity", Code: FieldRequired, Message: "is required when the profile has no city"}})
+		return
+	}
+
+	var offer db.RideOffer
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		if err := requireRegistration(ctx, q, userID, conferenceID); err != nil {
+			return err
+		}
+
+		offer, err = q.CreateRideOffer(ctx, db.CreateRideOfferParams{
+			ConferenceID:       conferenceID,
+			DriverID:           userID,
+			Seats:              req.Seats,
+			DepartureCity:      city,
+			DepartureTime:      departureTime,
+			DepartureLatitude:  nullFloat64(req.DepartureLatitude),
+			DepartureLongitude: nullFloat64(req.DepartureLongitude),
+			Notes:              nullString(req.Notes),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errRideAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+
+		return q.SetRegistrationRideFlags(ctx, db.SetRegistrationRideFlagsParams{
+			HasCar:       sql.NullBool{Bool: true, Valid: true},
+			UserID:       userID,
+			ConferenceID: conferenceID,
+		})
+	})
+	if err != nil {
+		writeRideError(w, r, err, "creating ride offer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toRideOfferResponse(offer)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode ride offer response", "error", err)
+	}
+}
+
+// DeleteRideOffer withdraws a ride offer. Its passengers go back to looking for a ride.
+func (s *Server) DeleteRideOffer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	offerID, err := uuid.Parse(r.PathValue("offer_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRideOfferID, "Invalid offer ID")
+		return
+	}
+
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		offer, err := getRideOffer(ctx, q, offerID)
+		if err != nil {
+			return err
+		}
+		if offer.DriverID != userID {
+			return errRideForbidden
+		}
+		return withdrawRideOffer(ctx, q, offer)
+	})
+	if err != nil {
+		writeRideError(w, r, err, "deleting ride offer")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRideOfferRequests lists the pending and accepted passengers of a ride offer (driver only)
+func (s *Server) ListRideOfferRequests(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	offerID, err := uuid.Parse(r.PathValue("offer_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRideOfferID, "Invalid offer ID")
+		return
+	}
+
+	offer, err := getRideOffer(ctx, s.db, offerID)
+	if err != nil {
+		writeRideError(w, r, err, "getting ride offer")
+		return
+	}
+	if offer.DriverID != userID {
+		writeRideError(w, r, errRideForbidden, "listing ride requests")
+		return
+	}
+
+	requests, err := s.db.ListRideRequestsByOffer(ctx, uuid.NullUUID{UUID: offerID, Valid: true})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing ride requests", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	response := make([]RideRequestResponse, len(requests))
+	for i, req := range requests {
+		passenger := rideUserResponse(req.PassengerID, req.Name, req.Nickname, req.City)
+		if req.Status == RideAccepted {
+			passenger.Email = &req.Email
+		}
+		response[i] = toRideRequestResponse(db.RideRequest{
+			ID:              req.ID,
+			ConferenceID:    req.ConferenceID,
+			PassengerID:     req.PassengerID,
+			OfferID:         req.OfferID,
+			Status:          req.Status,
+			PickupCity:      req.PickupCity,
+			PickupLatitude:  req.PickupLatitude,
+			PickupLongitude: req.PickupLongitude,
+			Notes:           req.Notes,
+			CreatedAt:       req.CreatedAt,
+			UpdatedAt:       req.UpdatedAt,
+		})
+		response[i].Passenger = &passenger
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode ride requests response", "error", err)
+	}
+}
+
+// CreateRideRequest registers that the authenticated user needs a ride to a conference
+func (s *Server) CreateRideRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conferenceID, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	var req CreateRideRequestRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	city, err := s.cityOrDefault(ctx, userID, req.PickupCity)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	var request db.RideRequest
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		if err := requireRegistration(ctx, q, userID, conferenceID); err != nil {
+			return err
+		}
+
+		request, err = q.CreateRideRequest(ctx, db.CreateRideRequestParams{
+			ConferenceID:    conferenceID,
+			PassengerID:     userID,
+			PickupCity:      sql.NullString{String: city, Valid: city != ""},
+			PickupLatitude:  nullFloat64(req.PickupLatitude),
+			PickupLongitude: nullFloat64(req.PickupLongitude),
+			Notes:           nullString(req.Notes),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errRideAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+
+		return setNeedsRide(ctx, q, userID, conferenceID, true)
+	})
+	if err != nil {
+		writeRideError(w, r, err, "creating ride request")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toRideRequestResponse(request)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode ride request response", "error", err)
+	}
+}
+
+// GetRideMatches ranks the ride offers of a conference for the authenticated user's ride request
+func (s *Server) GetRideMatches(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	conferenceID, err := uuid.Parse(r.PathValue("conference_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidConferenceID, "Invalid conference ID")
+		return
+	}
+
+	request, err := s.db.GetRideRequestByPassenger(ctx, db.GetRideRequestByPassengerParams{
+		ConferenceID: conferenceID,
+		PassengerID:  userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, CodeRideRequestNotFound, "Ride request not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error getting ride request", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	offers, err := s.db.ListRideOffersByConference(ctx, conferenceID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing ride offers", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+	declined, err := s.db.ListDeclinedRideOffers(ctx, db.ListDeclinedRideOffersParams{
+		ConferenceID: conferenceID,
+		PassengerID:  userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing declined ride offers", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rankRideMatches(request, offers, declined)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode ride matches response", "error", err)
+	}
+}
+
+// JoinRideOffer asks the driver of an offer for a seat, using the caller's ride request
+func (s *Server) JoinRideOffer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	offerID, err := uuid.Parse(r.PathValue("offer_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRideOfferID, "Invalid offer ID")
+		return
+	}
+
+	var request db.RideRequest
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		offer, err := getRideOffer(ctx, q, offerID)
+		if err != nil {
+			return err
+		}
+		if offer.DriverID == userID {
+			return errRideInvalidState
+		}
+
+		current, err := q.GetRideRequestByPassenger(ctx, db.GetRideRequestByPassengerParams{
+			ConferenceID: offer.ConferenceID,
+			PassengerID:  userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// A ride request must be created before joining an offer
+			return errRideRequestNotFound
+		}
+		if err != nil {
+			return err
+		}
+		current, err = lockRideRequest(ctx, q, current.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != RideOpen && current.Status != RideDeclined {
+			return errRideInvalidState
+		}
+		// A driver's decline is final for that offer: other offers can still be joined
+		declined, err := q.IsRideDeclined(ctx, db.IsRideDeclinedParams{
+			OfferID:     offer.ID,
+			PassengerID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if declined {
+			return errRideDeclined
+		}
+		if offer.SeatsTaken >= offer.Seats {
+			return errNoSeatsAvailable
+		}
+
+		request, err = q.UpdateRideRequestStatus(ctx, db.UpdateRideRequestStatusParams{
+			ID:      current.ID,
+			Status:  RidePending,
+			OfferID: uuid.NullUUID{UUID: offerID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		writeRideError(w, r, err, "joining ride offer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toRideRequestResponse(request)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode ride request response", "error", err)
+	}
+}
+
+// AcceptRideRequest lets the driver confirm a pending passenger, reserving a seat
+func (s *Server) AcceptRideRequest(w http.ResponseWriter, r *http.Request) {
+	s.answerRideRequest(w, r, true)
+}
+
+// DeclineRideRequest lets the driver refuse a pending or accepted passenger
+func (s *Server) DeclineRideRequest(w http.ResponseWriter, r *http.Request) {
+	s.answerRideRequest(w, r, false)
+}
+
+// answerRideRequest accepts or declines a ride request on behalf of the driver.
+// The request row is locked before the offer row so that seat counts stay
+// consistent with concurrent answers, cancellations and offer deletions.
+func (s *Server) answerRideRequest(w http.ResponseWriter, r *http.Request, accept bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(r.PathValue("request_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRideRequestID, "Invalid request ID")
+		return
+	}
+
+	var request db.RideRequest
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		current, err := lockRideRequest(ctx, q, requestID)
+		if err != nil {
+			return err
+		}
+		if !current.OfferID.Valid {
+			return errRideInvalidState
+		}
+		offer, err := getRideOffer(ctx, q, current.OfferID.UUID)
+		if err != nil {
+			return err
+		}
+		if offer.DriverID != userID {
+			return errRideForbidden
+		}
+
+		status := RideDeclined
+		switch {
+		case accept && current.Status == RidePending:
+			if _, err := q.ReserveRideSeat(ctx, offer.ID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return errNoSeatsAvailable
+				}
+				return err
+			}
+			status = RideAccepted
+		case !accept && current.Status == RidePending:
+		case !accept && current.Status == RideAccepted:
+			if err := q.ReleaseRideSeat(ctx, offer.ID); err != nil {
+				return err
+			}
+		default:
+			return errRideInvalidState
+		}
+		if status == RideDeclined {
+			if err := q.RecordRideDecline(ctx, db.RecordRideDeclineParams{
+				OfferID:     offer.ID,
+				PassengerID: current.PassengerID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		request, err = q.UpdateRideRequestStatus(ctx, db.UpdateRideRequestStatusParams{
+			ID:      current.ID,
+			Status:  status,
+			OfferID: current.OfferID,
+		})
+		if err != nil {
+			return err
+		}
+		return setNeedsRide(ctx, q, current.PassengerID, current.ConferenceID, status != RideAccepted)
+	})
+	if err != nil {
+		writeRideError(w, r, err, "answering ride request")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toRideRequestResponse(request)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode ride request response", "error", err)
+	}
+}
+
+// CancelRideRequest withdraws the authenticated user's ride request, freeing the seat if reserved
+func (s *Server) CancelRideRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(r.PathValue("request_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRideRequestID, "Invalid request ID")
+		return
+	}
+
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		current, err := lockRideRequest(ctx, q, requestID)
+		if err != nil {
+			return err
+		}
+		if current.PassengerID != userID {
+			// Requests of other users are reported as not found to avoid leaking their existence
+			return errRideRequestNotFound
+		}
+		if current.Status == RideCancelled {
+			return errRideInvalidState
+		}
+		return cancelRideRequest(ctx, q, current)
+	})
+	if err != nil {
+		writeRideError(w, r, err, "cancelling ride request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withdrawRideOffer deletes offer; its pending and accepted passengers go back to looking for a ride
+func withdrawRideOffer(ctx context.Context, q db.Querier, offer db.RideOffer) error {
+	passengers, err := q.ReopenRideRequestsByOffer(ctx, uuid.NullUUID{UUID: offer.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, passengerID := range passengers {
+		if err := setNeedsRide(ctx, q, passengerID, offer.ConferenceID, true); err != nil {
+			return err
+		}
+	}
+
+	if err := q.DeleteRideOffer(ctx, offer.ID); err != nil {
+		return err
+	}
+	return q.SetRegistrationRideFlags(ctx, db.SetRegistrationRideFlagsParams{
+		HasCar:       sql.NullBool{Bool: false, Valid: true},
+		UserID:       offer.DriverID,
+		ConferenceID: offer.ConferenceID,
+	})
+}
+
+// cancelRideRequest cancels a locked ride request, freeing the seat if one was reserved
+func cancelRideRequest(ctx context.Context, q db.Querier, request db.RideRequest) error {
+	if request.Status == RideAccepted && request.OfferID.Valid {
+		if err := q.ReleaseRideSeat(ctx, request.OfferID.UUID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.UpdateRideRequestStatus(ctx, db.UpdateRideRequestStatusParams{
+		ID:     request.ID,
+		Status: RideCancelled,
+	}); err != nil {
+		return err
+	}
+	return setNeedsRide(ctx, q, request.PassengerID, request.ConferenceID, false)
+}
+
+// cancelUserRides withdraws the ride offer and cancels the ride request of a user
+// for a conference, for example when the user cancels the registration.
+// The request is handled first, so rows are locked in the same order as answerRideRequest.
+func cancelUserRides(ctx context.Context, q db.Querier, userID, conferenceID uuid.UUID) error {
+	request, err := q.GetRideRequestByPassenger(ctx, db.GetRideRequestByPassengerParams{
+		ConferenceID: conferenceID,
+		PassengerID:  userID,
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return err
+	default:
+		if request, err = q.LockRideRequest(ctx, request.ID); err != nil {
+			return err
+		}
+		if request.Status != RideCancelled {
+			if err := cancelRideRequest(ctx, q, request); err != nil {
+				return err
+			}
+		}
+	}
+
+	offer, err := q.GetRideOfferByDriver(ctx, db.GetRideOfferByDriverParams{
+		ConferenceID: conferenceID,
+		DriverID:     userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return withdrawRideOffer(ctx, q, offer)
+}
+
+// cityOrDefault returns the given city, or the user's profile city if none was provided
+func (s *Server) cityOrDefault(ctx context.Context, userID uuid.UUID, city *string) (string, error) {
+	if city != nil && strings.TrimSpace(*city) != "" {
+		return strings.TrimSpace(*city), nil
+	}
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(user.City.String), nil
+}
+
+// rideUserResponse builds the public user information shown in carpooling listings.
+// The email is left out: callers add it only for accepted rides.
+func rideUserResponse(id uuid.UUID, name string, nickname, city sql.NullString) RideParticipant {
+	return RideParticipant{
+		ID:       id.String(),
+		Name:     name,
+		Nickname: stringPtr(nickname),
+		City:     stringPtr(city),
+	}
+}
+
+// toRideOfferResponse converts a database ride offer to the API response format
+func toRideOfferResponse(o db.RideOffer) RideOfferResponse {
+	return RideOfferResponse{
+		ID:                 o.ID.String(),
+		ConferenceID:       o.ConferenceID.String(),
+		DriverID:           o.DriverID.String(),
+		Seats:              o.Seats,
+		SeatsAvailable:     max(o.Seats-o.SeatsTaken, 0),
+		DepartureCity:      o.DepartureCity,
+		DepartureTime:      o.DepartureTime.Format(time.RFC3339),
+		DepartureLatitude:  float64Ptr(o.DepartureLatitude),
+		DepartureLongitude: float64Ptr(o.DepartureLongitude),
+		Notes:              stringPtr(o.Notes),
+	}
+}
+
+// toRideOfferRowResponse converts a ride offer joined with its driver to the API response format
+func toRideOfferRowResponse(o db.ListRideOffersByConferenceRow) RideOfferResponse {
+	response := toRideOfferResponse(db.RideOffer{
+		ID:                 o.ID,
+		ConferenceID:       o.ConferenceID,
+		DriverID:           o.DriverID,
+		Seats:              o.Seats,
+		SeatsTaken:         o.SeatsTaken,
+		DepartureCity:      o.DepartureCity,
+		DepartureTime:      o.DepartureTime,
+		DepartureLatitude:  o.DepartureLatitude,
+		DepartureLongitude: o.DepartureLongitude,
+		Notes:              o.Notes,
+		CreatedAt:          o.CreatedAt,
+	})
+	driver := rideUserResponse(o.DriverID, o.Name, o.Nickname, o.City)
+	response.Driver = &driver
+	return response
+}
+
+// toRideRequestResponse converts a database ride request to the API response format
+func toRideRequestResponse(r db.RideRequest) RideRequestResponse {
+	response := RideRequestResponse{
+		ID:              r.ID.String(),
+		ConferenceID:    r.ConferenceID.String(),
+		PassengerID:     r.PassengerID.String(),
+		Status:          r.Status,
+		PickupCity:      stringPtr(r.PickupCity),
+		PickupLatitude:  float64Ptr(r.PickupLatitude),
+		PickupLongitude: float64Ptr(r.PickupLongitude),
+		Notes:           stringPtr(r.Notes),
+		UpdatedAt:       timePtr(r.UpdatedAt),
+	}
+	if r.OfferID.Valid {
+		offerID := r.OfferID.UUID.String()
+		response.OfferID = &offerID
+	}
+	return response
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// Test ordinamento delle offerte: stessa città, poi distanza, poi orario di partenza
+func TestRankRideMatches(t *testing.T) {
+	passenger := uuid.New()
+	base := time.Date(2026, 5, 10, 8, 0, 0, 0, time.UTC)
+	coord := func(f float64) sql.NullFloat64 { return sql.NullFloat64{Float64: f, Valid: true} }
+
+	offer := func(city string, departure time.Time, lat, lng sql.NullFloat64) db.ListRideOffersByConferenceRow {
+		return db.ListRideOffersByConferenceRow{
+			ID:                 uuid.New(),
+			DriverID:           uuid.New(),
+			Seats:              3,
+			DepartureCity:      city,
+			DepartureTime:      departure,
+			DepartureLatitude:  lat,
+			DepartureLongitude: lng,
+		}
+	}
+
+	bergamo := offer("Bergamo", base, coord(45.6983), coord(9.6773))
+	torino := offer("Torino", base, coord(45.0703), coord(7.6869))
+	milanoLate := offer("milano ", base.Add(time.Hour), sql.NullFloat64{}, sql.NullFloat64{})
+	milanoEarly := offer("Milano", base, sql.NullFloat64{}, sql.NullFloat64{})
+	unknown := offer("Napoli", base.Add(-time.Hour), sql.NullFloat64{}, sql.NullFloat64{})
+
+	full := offer("Milano", base, sql.NullFloat64{}, sql.NullFloat64{})
+	full.SeatsTaken = full.Seats
+	own := offer("Milano", base, sql.NullFloat64{}, sql.NullFloat64{})
+	own.DriverID = passenger
+	declined := offer("Milano", base, sql.NullFloat64{}, sql.NullFloat64{})
+
+	request := db.RideRequest{
+		PassengerID:     passenger,
+		PickupCity:      sql.NullString{String: "Milano", Valid: true},
+		PickupLatitude:  coord(45.4642),
+		PickupLongitude: coord(9.1900),
+	}
+
+	matches := rankRideMatches(request, []db.ListRideOffersByConferenceRow{unknown, torino, full, milanoLate, own, declined, bergamo, milanoEarly}, []uuid.UUID{declined.ID})
+
+	want := []uuid.UUID{milanoEarly.ID, milanoLate.ID, bergamo.ID, torino.ID, unknown.ID}
+	if len(matches) != len(want) {
+		t.Fatalf("Expected %d matches, got %d", len(want), len(matches))
+	}
+	for i, id := range want {
+		if matches[i].Offer.ID != id.String() {
+			t.Errorf("Match %d: expected offer %s (%s), got %s", i, id, matches[i].Offer.DepartureCity, matches[i].Offer.ID)
+		}
+	}
+	if !matches[0].SameCity || matches[2].SameCity {
+		t.Error("Expected same city flag only on offers leaving from Milano")
+	}
+	if matches[2].DistanceKm == nil || matches[0].DistanceKm != nil {
+		t.Error("Expected distance only when both points have coordinates")
+	}
+}
+
+// Test dati pubblici di autisti e passeggeri: l'email non compare nelle liste
+func TestRideOfferResponseHidesEmail(t *testing.T) {
+	response := toRideOfferRowResponse(db.ListRideOffersByConferenceRow{
+		ID:       uuid.New(),
+		DriverID: uuid.New(),
+		Seats:    3,
+		Email:    "autista@example.com",
+		Name:     "Mario Rossi",
+	})
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Contains(string(body), "autista@example.com") {
+		t.Errorf("Expected driver email to be hidden, got %s", body)
+	}
+	if response.Driver == nil || response.Driver.Name != "Mario Rossi" {
+		t.Errorf("Expected driver name in the response, got %+v", response.Driver)
+	}
+}
+
+// Test codici di errore distinti per offerte e richieste di passaggio non trovate
+func TestWriteRideError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errRideOfferNotFound, http.StatusNotFound, CodeRideOfferNotFound},
+		{fmt.Errorf("joining: %w", errRideRequestNotFound), http.StatusNotFound, CodeRideRequestNotFound},
+		{errRideDeclined, http.StatusConflict, CodeRideDeclined},
+		{errNotRegistered, http.StatusForbidden, CodeRegistrationRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writeRideError(rr, httptest.NewRequest("POST", "/api/v1/rides/offers/1/join", nil), tt.err, "testing")
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tt.code+`"`) {
+				t.Errorf("Expected code %s, got %s", tt.code, rr.Body.String())
+			}
+		})
+	}
+}
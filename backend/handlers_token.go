@@ -5,95 +5,160 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
-// GetTokens retrieves all tokens for a specific user
+// GetTokens retrieves all tokens of the authenticated user
 func (s *Server) GetTokens(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userIDStr := r.URL.Query().Get("userId")
-	if userIDStr == "" {
-		http.Error(w, "User ID required", http.StatusBadRequest)
-		return
-	}
-
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+	userID, ok := contextUserID(w, r)
+	if !ok {
 		return
 	}
+	currentID, _ := r.Context().Value(TokenIDKey).(uuid.UUID)
 
 	tokens, err := s.db.GetTokensByUser(ctx, userID)
 	if err != nil {
-		log.Printf("Error getting tokens: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting tokens", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
 	response := make([]TokenResponse, len(tokens))
 	for i, t := range tokens {
-		response[i] = TokenResponse{
-			ID:         t.ID.String(),
-			CreatedAt:  *timePtr(t.CreatedAt),
-			LastUsedAt: timePtr(t.LastUsedAt),
-			Revoked:    t.Revoked,
-		}
+		response[i] = s.toTokenResponse(t, currentID)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Failed to encode tokens response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode tokens response", "error", err)
 	}
 }
 
-// RevokeToken revokes a specific token
+// RevokeToken revokes one of the authenticated user's tokens
 func (s *Server) RevokeToken(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
 	// Accept POST or DELETE for revocation
 	if r.Method != "POST" && r.Method != "DELETE" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	currentID, _ := r.Context().Value(TokenIDKey).(uuid.UUID)
+
 	idStr := r.URL.Query().Get("id")
 	if idStr == "" {
-		http.Error(w, "Token ID required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidTokenID, "Token ID required")
 		return
 	}
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidTokenID, "Invalid token ID")
 		return
 	}
 
-	token, err := s.db.RevokeToken(ctx, id)
+	// Tokens of other users are reported as not found to avoid leaking their existence
+	token, err := s.db.RevokeUserToken(ctx, db.RevokeUserTokenParams{
+		ID:     id,
+		UserID: userID,
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Token not found", http.StatusNotFound)
+			writeError(w, http.StatusNotFound, CodeTokenNotFound, "Token not found")
 			return
 		}
-		log.Printf("Error revoking token: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error revoking token", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
-	resp := TokenResponse{
-		ID:         token.ID.String(),
-		CreatedAt:  *timePtr(token.CreatedAt),
-		LastUsedAt: timePtr(token.LastUsedAt),
-		Revoked:    token.Revoked,
-	}
+	resp := s.toTokenResponse(token, currentID)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("Failed to encode revoke token response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode revoke token response", "error", err)
+	}
+}
+
+// RevokeOtherTokens revokes every token of the authenticated user except the current one
+func (s *Server) RevokeOtherTokens(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	currentID, ok := contextTokenID(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, err := s.db.RevokeOtherUserTokens(ctx, db.RevokeOtherUserTokensParams{
+		UserID: userID,
+		ID:     currentID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error revoking other tokens", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RevokeOtherTokensResponse{Revoked: revoked}); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode revoke other tokens response", "error", err)
+	}
+}
+
+// Logout revokes the token used to authenticate the current request
+func (s *Server) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	currentID, ok := contextTokenID(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := s.db.RevokeUserToken(ctx, db.RevokeUserTokenParams{
+		ID:     currentID,
+		UserID: userID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error revoking current token", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toTokenResponse converts a database token to the API response format
+func (s *Server) toTokenResponse(t db.UserToken, currentID uuid.UUID) TokenResponse {
+	return TokenResponse{
+		ID:         t.ID.String(),
+		CreatedAt:  *timePtr(t.CreatedAt),
+		LastUsedAt: timePtr(t.LastUsedAt),
+		ExpiresAt:  s.tokens.ExpiresAt(t).Format(time.RFC3339),
+		Revoked:    t.Revoked,
+		Current:    t.ID == currentID,
 	}
 }
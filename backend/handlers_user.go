@@ -5,38 +5,42 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
-// Register handles user registration
+// Register handles user registration and emails the link verifying the address
 func (s *Server) Register(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
-	if req.Email == "" || req.Password == "" || req.Name == "" {
-		http.Error(w, "Email, password and name are required", http.StatusBadRequest)
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if !s.limitAuthAttempt(w, r, req.Email) {
 		return
 	}
 
 	existingUser, err := s.db.GetUserByEmail(ctx, req.Email)
 	if err == nil && existingUser.ID != uuid.Nil {
-		http.Error(w, "Email already registered", http.StatusConflict)
+		writeError(w, http.StatusConflict, CodeEmailTaken, "Email already registered")
 		return
 	}
 
 	passwordHash, err := db.HashPassword(req.Password)
 	if err != nil {
-		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to hash password")
 		return
 	}
 
@@ -50,14 +54,14 @@ func (s *Server) Register(w http.ResponseWriter, r *http.Request) {
 		Bio:       nullString(req.Bio),
 	})
 	if err != nil {
-		log.Printf("Error creating user: %v", err)
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error creating user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to create user")
 		return
 	}
 
-	token, err := generateToken()
+	token, err := generateToken(s.cfg.Tokens.Size)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to generate token")
 		return
 	}
 
@@ -68,53 +72,73 @@ func (s *Server) Register(w http.ResponseWriter, r *http.Request) {
 		TokenHash: tokenHash,
 	})
 	if err != nil {
-		log.Printf("Error saving token: %v", err)
-		http.Error(w, "Failed to save token", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error saving token", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to save token")
 		return
 	}
 
+	// The account exists even if the email cannot be sent: the user can ask for a new one
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		slog.ErrorContext(r.Context(), "Error sending verification email", "error", err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(RegisterResponse{User: toUserResponse(user), Token: token}); err != nil {
-		log.Printf("Failed to encode register response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode register response", "error", err)
 	}
 }
 
 // Login handles user authentication
 func (s *Server) Login(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	if req.Email == "" || req.Password == "" {
-		http.Error(w, "Email and password are required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeValidationFailed, "Email and password are required")
+		return
+	}
+
+	if !s.checkLoginLockout(w, r, req.Email) || !s.limitAuthAttempt(w, r, req.Email) {
 		return
 	}
 
 	user, err := s.db.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+			// Unknown emails count as failures too and cost a password check,
+			// so they cannot be told apart by response or timing
+			db.CheckDummyPassword(req.Password)
+			s.recordLoginFailure(r, req.Email)
+			writeError(w, http.StatusUnauthorized, CodeInvalidCredentials, "Invalid credentials")
 			return
 		}
-		log.Printf("Error getting user: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
 		return
 	}
 
 	if !db.CheckPasswordHash(req.Password, user.Password) {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		s.recordLoginFailure(r, req.Email)
+		writeError(w, http.StatusUnauthorized, CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
+	s.resetLoginFailures(r, req.Email)
 
-	token, err := generateToken()
+	// Transparently upgrade legacy or outdated password hashes
+	if db.PasswordNeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, req.Password)
+	}
+
+	token, err := generateToken(s.cfg.Tokens.Size)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to generate token")
 		return
 	}
 
@@ -125,85 +149,87 @@ func (s *Server) Login(w http.ResponseWriter, r *http.Request) {
 		TokenHash: tokenHash,
 	})
 	if err != nil {
-		log.Printf("Error saving token: %v", err)
-		http.Error(w, "Failed to save token", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error saving token", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to save token")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(LoginResponse{User: toUserResponse(user), Token: token}); err != nil {
-		log.Printf("Failed to encode login response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode login response", "error", err)
 	}
 }
 
 // GetMe retrieves user information by user ID from query parameter
 func (s *Server) GetMe(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
 	userIDStr := r.PathValue("user_id")
 	if userIDStr == "" {
-		http.Error(w, "User ID required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidUserID, "User ID required")
 		return
 	}
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidUserID, "Invalid user ID")
 		return
 	}
 
 	user, err := s.db.GetUserByID(ctx, userID)
 	if err != nil {
-		log.Printf("Error getting user: %v", err)
-		http.Error(w, "User not found", http.StatusNotFound)
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusNotFound, CodeUserNotFound, "User not found")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(toUserResponse(user)); err != nil {
-		log.Printf("Failed to encode user response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode user response", "error", err)
 	}
 }
 
 // GetMeFromToken retrieves user information from the authentication token
 func (s *Server) GetMeFromToken(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusNotFound)
 		return
 	}
 
 	user, err := s.db.GetUserByID(ctx, userID)
 	if err != nil {
-		log.Printf("Error getting user: %v", err)
-		http.Error(w, "User not found", http.StatusNotFound)
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusNotFound, CodeUserNotFound, "User not found")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(toUserResponse(user)); err != nil {
-		log.Printf("Failed to encode user response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode user response", "error", err)
 	}
 }
 
 // UpdateMe updates the authenticated user's information
 func (s *Server) UpdateMe(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), RequestTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
 	defer cancel()
 
-	userID, ok := r.Context().Value(UserIDKey).(uuid.UUID)
+	userID, ok := contextUserID(w, r)
 	if !ok {
-		http.Error(w, "User not found in context", http.StatusUnauthorized)
 		return
 	}
 
 	var req UpdateMeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
 		return
 	}
 
@@ -216,13 +242,67 @@ func (s *Server) UpdateMe(w http.ResponseWriter, r *http.Request) {
 		Bio:       nullString(req.Bio),
 	})
 	if err != nil {
-		log.Printf("Error updating user: %v", err)
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error updating user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to update user")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(toUserResponse(updatedUser)); err != nil {
-		log.Printf("Failed to encode user response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode user response", "error", err)
+	}
+}
+
+// rehashPassword stores a fresh hash of the password using the configured hasher.
+// Failures are only logged: the user has already been authenticated.
+func (s *Server) rehashPassword(ctx context.Context, user db.User, password string) {
+	passwordHash, err := db.HashPassword(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error rehashing password", "user_id", user.ID, "error", err)
+		return
+	}
+
+	if _, err := s.db.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:       user.ID,
+		Password: passwordHash,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Error updating password hash", "user_id", user.ID, "error", err)
+	}
+}
+
+// SetUserAdmin grants or revokes platform administrator rights (admin only)
+func (s *Server) SetUserAdmin(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidUserID, "Invalid user ID")
+		return
+	}
+
+	var req SetAdminRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	user, err := s.db.SetUserAdmin(ctx, db.SetUserAdminParams{
+		ID:      userID,
+		IsAdmin: req.IsAdmin,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, CodeUserNotFound, "User not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error updating admin flag", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to update user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toUserResponse(user)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode user response", "error", err)
 	}
 }
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// Readiness check statuses
+const (
+	CheckOK   = "ok"
+	CheckFail = "fail"
+)
+
+// ReadinessCheck is a dependency that must be healthy for the server to take traffic.
+// Check may return details, e.g. counters, that are reported even when it fails.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) (details any, err error)
+}
+
+// SetReadinessChecks sets the checks run by /readyz. It must be called before Run.
+func (s *Server) SetReadinessChecks(checks ...ReadinessCheck) {
+	s.checks = checks
+}
+
+// Livez reports that the process is up and serving HTTP. It does not look at
+// dependencies, so an orchestrator does not restart the server when the database is down.
+func (s *Server) Livez(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, r, http.StatusOK, HealthResponse{Status: CheckOK})
+}
+
+// Readyz runs all readiness checks concurrently, each within the configured
+// timeout, and returns 503 with the failing checks if any of them fails
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	results := make([]CheckResult, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(r.Context(), check, s.cfg.Health.CheckTimeout)
+		}()
+	}
+	wg.Wait()
+
+	resp := HealthResponse{Status: CheckOK, Checks: make(map[string]CheckResult, len(results))}
+	status := http.StatusOK
+	for i, result := range results {
+		resp.Checks[s.checks[i].Name] = result
+		if result.Status != CheckOK {
+			resp.Status = CheckFail
+			status = http.StatusServiceUnavailable
+			slog.WarnContext(r.Context(), "Readiness check failed", "check", s.checks[i].Name, "error", result.Error)
+		}
+	}
+	writeHealth(w, r, status, resp)
+}
+
+// runCheck executes a single check and measures it
+func runCheck(ctx context.Context, check ReadinessCheck, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Check(ctx)
+	result := CheckResult{Status: CheckOK, DurationMs: time.Since(start).Milliseconds(), Details: details}
+	if err != nil {
+		result.Status = CheckFail
+		result.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = fmt.Sprintf("timed out after %s", timeout)
+		}
+	}
+	return result
+}
+
+// writeHealth writes a health response; health endpoints must never be cached
+func writeHealth(w http.ResponseWriter, r *http.Request, status int, resp HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode health response", "error", err)
+	}
+}
+
+// databaseChecks returns the readiness checks of the PostgreSQL database:
+// connectivity, pending migrations and connection pool saturation
+func databaseChecks(sqlDB *sql.DB, migrator *db.Migrator, saturation float64) []ReadinessCheck {
+	return []ReadinessCheck{
+		{Name: "database", Check: func(ctx context.Context) (any, error) {
+			return nil, sqlDB.PingContext(ctx)
+		}},
+		{Name: "migrations", Check: func(ctx context.Context) (any, error) {
+			return checkMigrations(ctx, migrator)
+		}},
+		{Name: "pool", Check: func(ctx context.Context) (any, error) {
+			return checkPool(sqlDB.Stats(), saturation)
+		}},
+	}
+}
+
+// checkMigrations fails while migrations embedded in the binary are not applied
+func checkMigrations(ctx context.Context, migrator *db.Migrator) (any, error) {
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		versions := make([]int64, len(pending))
+		for i, m := range pending {
+			versions[i] = m.Version
+		}
+		return map[string]any{"pending": versions}, fmt.Errorf("%d pending migrations", len(pending))
+	}
+	return nil, nil
+}
+
+// checkPool fails when the connections in use reach the saturation fraction of
+// MaxOpenConns. Without a connection limit the pool cannot saturate.
+func checkPool(stats sql.DBStats, saturation float64) (any, error) {
+	details := PoolStats{
+		InUse:     stats.InUse,
+		Idle:      stats.Idle,
+		MaxOpen:   stats.MaxOpenConnections,
+		WaitCount: stats.WaitCount,
+	}
+	if stats.MaxOpenConnections > 0 && float64(stats.InUse) >= saturation*float64(stats.MaxOpenConnections) {
+		return details, fmt.Errorf("%d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+	}
+	return details, nil
+}
+
+// pinger is implemented by *sql.DB
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Backoff between attempts to reach the database at startup
+const (
+	connectAttemptTimeout = 5 * time.Second
+	connectMinBackoff     = 500 * time.Millisecond
+	connectMaxBackoff     = 5 * time.Second
+)
+
+// waitForDatabase pings the database until it answers, retrying with
+// exponential backoff for up to timeout. With a zero timeout it tries once.
+func waitForDatabase(ctx context.Context, database pinger, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := connectMinBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, connectAttemptTimeout)
+		err := database.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+
+		slog.Warn("Database not reachable, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, connectMaxBackoff)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test risposta di /readyz con il dettaglio dei singoli controlli
+func TestReadyz(t *testing.T) {
+	ok := ReadinessCheck{Name: "database", Check: func(ctx context.Context) (any, error) { return nil, nil }}
+	failing := ReadinessCheck{Name: "migrations", Check: func(ctx context.Context) (any, error) {
+		return map[string]any{"pending": []int64{2}}, errors.New("1 pending migrations")
+	}}
+	slow := ReadinessCheck{Name: "slow", Check: func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	tests := []struct {
+		name   string
+		checks []ReadinessCheck
+		status int
+		failed []string
+	}{
+		{"No checks", nil, http.StatusOK, nil},
+		{"All checks pass", []ReadinessCheck{ok}, http.StatusOK, nil},
+		{"Failing check", []ReadinessCheck{ok, failing}, http.StatusServiceUnavailable, []string{"migrations"}},
+		{"Check timing out", []ReadinessCheck{ok, slow}, http.StatusServiceUnavailable, []string{"slow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Health.CheckTimeout = 20 * time.Millisecond
+			s := NewServer(nil, cfg)
+			s.SetReadinessChecks(tt.checks...)
+
+			rr := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			var resp HealthResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(resp.Checks) != len(tt.checks) {
+				t.Errorf("Expected %d checks, got %+v", len(tt.checks), resp.Checks)
+			}
+			for _, name := range tt.failed {
+				if resp.Status != CheckFail || resp.Checks[name].Status != CheckFail || resp.Checks[name].Error == "" {
+					t.Errorf("Expected check %s to fail with an error, got %+v", name, resp)
+				}
+			}
+		})
+	}
+}
+
+// Test /livez e alias /health indipendenti dai controlli di readiness
+func TestLivez(t *testing.T) {
+	s := NewServer(nil, DefaultConfig())
+	s.SetReadinessChecks(ReadinessCheck{Name: "database", Check: func(ctx context.Context) (any, error) {
+		return nil, errors.New("connection refused")
+	}})
+
+	for _, path := range []string{"/livez", "/health"} {
+		rr := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", path, rr.Code)
+		}
+		if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("%s: expected Cache-Control no-store, got %q", path, got)
+		}
+	}
+}
+
+// Test saturazione del pool di connessioni
+func TestCheckPool(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats sql.DBStats
+		fail  bool
+	}{
+		{"Idle pool", sql.DBStats{MaxOpenConnections: 10, InUse: 1, Idle: 4}, false},
+		{"Below threshold", sql.DBStats{MaxOpenConnections: 10, InUse: 8}, false},
+		{"Saturated", sql.DBStats{MaxOpenConnections: 10, InUse: 9}, true},
+		{"Unlimited pool", sql.DBStats{InUse: 500}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := checkPool(tt.stats, 0.9)
+			if (err != nil) != tt.fail {
+				t.Errorf("Expected fail=%v, got error %v", tt.fail, err)
+			}
+			if stats, ok := details.(PoolStats); !ok || stats.InUse != tt.stats.InUse {
+				t.Errorf("Expected pool details, got %+v", details)
+			}
+		})
+	}
+}
+
+// fakePinger fallisce le prime failures chiamate
+type fakePinger struct {
+	failures int
+	calls    int
+}
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// Test attesa del database all'avvio con retry
+func TestWaitForDatabase(t *testing.T) {
+	t.Run("Fail fast without timeout", func(t *testing.T) {
+		p := &fakePinger{failures: 1}
+		if err := waitForDatabase(context.Background(), p, 0); err == nil || p.calls != 1 {
+			t.Errorf("Expected a single failed attempt, got %d calls and error %v", p.calls, err)
+		}
+	})
+
+	t.Run("Retries until reachable", func(t *testing.T) {
+		p := &fakePinger{failures: 1}
+		if err := waitForDatabase(context.Background(), p, time.Minute); err != nil || p.calls != 2 {
+			t.Errorf("Expected success on the second attempt, got %d calls and error %v", p.calls, err)
+		}
+	})
+
+	t.Run("Stops when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p := &fakePinger{failures: 10}
+		if err := waitForDatabase(ctx, p, time.Minute); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
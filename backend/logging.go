@@ -1,7 +1,10 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -36,36 +39,136 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
-// loggingMiddleware logs HTTP requests with method, path, status, duration, size, and user
+// requestLog collects request details that are only known further down the
+// chain, such as the user authenticated by authMiddleware. loggingMiddleware
+// stores it in the context before calling the next handler.
+type requestLog struct {
+	userID uuid.UUID
+}
+
+// setLogUser records the authenticated user of the request for logging
+func setLogUser(ctx context.Context, userID uuid.UUID) {
+	if rl, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		rl.userID = userID
+	}
+}
+
+// logUser returns the authenticated user recorded for the request, if any
+func logUser(ctx context.Context) (uuid.UUID, bool) {
+	if rl, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		return rl.userID, rl.userID != uuid.Nil
+	}
+	if userID, ok := ctx.Value(UserIDKey).(uuid.UUID); ok {
+		return userID, true
+	}
+	return uuid.Nil, false
+}
+
+// contextHandler adds the request ID and the authenticated user found in the
+// context to every record, so handler logs can be correlated with the request.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id, ok := ctx.Value(RequestIDKey).(string); ok {
+		rec.AddAttrs(slog.String("request_id", id))
+	}
+	if userID, ok := logUser(ctx); ok {
+		rec.AddAttrs(slog.String("user_id", userID.String()))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// newLogger creates a logger writing to w in the given format (text or json),
+// discarding messages below level (debug, info, warn or error)
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// loggingMiddleware logs every HTTP request with method, path, status, size,
+// duration and, once authMiddleware has run, the authenticated user.
+// Server errors are logged at error level, everything else at info level.
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
 		// Wrap the response writer to capture status and size
 		wrapped := newResponseWriter(w)
+		ctx := context.WithValue(r.Context(), requestLogKey, &requestLog{})
 
-		// Get user ID from context if available
-		userID := "anonymous"
-		if uid, ok := r.Context().Value(UserIDKey).(uuid.UUID); ok {
-			userID = uid.String()
-		}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
 
-		// Process the request
-		next.ServeHTTP(wrapped, r)
-
-		// Calculate duration
-		duration := time.Since(start)
-
-		// Log the request with all details
-		log.Printf(
-			"[%s] %s %s | Status: %d | Size: %d bytes | Duration: %v | User: %s",
-			r.RemoteAddr,
-			r.Method,
-			r.URL.Path,
-			wrapped.status,
-			wrapped.size,
-			duration,
-			userID,
+		level := slog.LevelInfo
+		if wrapped.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrapped.status),
+			slog.Int("size", wrapped.size),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
 		)
 	})
 }
+
+// requestIDHeader carries the request ID in requests and responses
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// requestIDMiddleware assigns every request an ID, exposed in the X-Request-ID
+// response header and stored in the context under RequestIDKey.
+// A well-formed X-Request-ID sent by a client or proxy is reused.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), RequestIDKey, id)))
+	})
+}
+
+// validRequestID accepts short IDs made of letters, digits, '-', '_' and '.'
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// Test scrittura delle email su file per lo sviluppo locale
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(&buf, "conferenze.tech <noreply@conferenze.tech>")
+
+	link := "http://localhost:5173/verifica-email?token=" + strings.Repeat("x", 100)
+	err := m.Send(context.Background(), Message{To: "mario@example.com", Subject: "Conferma l'indirizzo", Body: "Apri " + link})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"From: \"conferenze.tech\" <noreply@conferenze.tech>\r\n", "To: <mario@example.com>\r\n", link} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in %q", want, out)
+		}
+	}
+}
+
+// Test formattazione del messaggio e rifiuto degli indirizzi non validi
+func TestFormatMessage(t *testing.T) {
+	data, err := formatMessage("noreply@conferenze.tech", Message{To: "mario@example.com", Subject: "Città", Body: "Perché sì"}, true)
+	if err != nil {
+		t.Fatalf("formatMessage failed: %v", err)
+	}
+	out := string(data)
+	if !strings.Contains(out, "Subject: =?utf-8?q?Citt=C3=A0?=\r\n") || !strings.Contains(out, "Perch=C3=A9 s=C3=AC") {
+		t.Errorf("Expected encoded subject and body, got %q", out)
+	}
+
+	for _, to := range []string{"", "not an address", "mario@example.com\r\nBcc: victim@example.com"} {
+		if _, err := formatMessage("noreply@conferenze.tech", Message{To: to, Subject: "x", Body: "x"}, true); err == nil {
+			t.Errorf("Expected error for recipient %q", to)
+		}
+	}
+}
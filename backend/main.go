@@ -1,34 +1,96 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/lib/pq"
 	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
 func main() {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		dsn = DefaultDSN
+	cfg, args, err := LoadConfig(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatalf("configurazione non valida: %v", err)
+	}
+
+	// Il logger di default riceve anche i messaggi del package log
+	logger, err := newLogger(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("configurazione logging non valida: %v", err)
 	}
+	slog.SetDefault(logger)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = DefaultPort
+	// La validazione della configurazione garantisce che l'algoritmo sia supportato
+	hasher, err := db.NewPasswordHasher(cfg.PasswordHasher)
+	if err != nil {
+		log.Fatalf("configurazione hashing password non valida: %v", err)
 	}
+	db.SetPasswordHasher(hasher)
 
-	sqlDB, err := sql.Open("postgres", dsn)
+	sqlDB, err := sql.Open("postgres", cfg.Database.URL)
 	if err != nil {
 		log.Fatalf("impossibile connettersi al database: %v", err)
 	}
-	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
 
-	queries := db.New(sqlDB)
-	server := NewServer(queries)
-	if err := server.Run(port); err != nil {
-		log.Fatalf("server error: %v", err)
+	// sql.Open non apre connessioni: all'avvio si attende che il database risponda
+	if err := waitForDatabase(context.Background(), sqlDB, cfg.Database.ConnectTimeout); err != nil {
+		sqlDB.Close()
+		log.Fatalf("impossibile connettersi al database: %v", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		err := runMigrateCommand(sqlDB, args[1:], os.Stdout)
+		sqlDB.Close()
+		if err != nil {
+			log.Fatalf("migrazione fallita: %v", err)
+		}
+		return
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := autoMigrate(sqlDB); err != nil {
+			log.Fatalf("migrazione all'avvio fallita: %v", err)
+		}
+	}
+
+	queries := db.WrapDB(db.New(sqlDB))
+
+	// SIGINT/SIGTERM cancel ctx: Run drains in-flight requests and returns
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := NewServer(queries, cfg)
+	server.CollectDBStats(sqlDB)
+	migrator, err := db.NewMigrator(sqlDB)
+	if err != nil {
+		log.Fatalf("migrazioni non valide: %v", err)
+	}
+	server.SetReadinessChecks(databaseChecks(sqlDB, migrator, cfg.Health.PoolSaturation)...)
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		log.Fatalf("configurazione email non valida: %v", err)
+	}
+	server.SetMailer(mailer)
+	if cfg.Accounts.TokenSecret == "" {
+		slog.Warn("ACCOUNT_TOKEN_SECRET non impostato: chiave casuale per lo sviluppo, i link delle email non saranno validi dopo un riavvio")
+	}
+	runErr := server.Run(ctx, cfg.Port)
+
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("errore chiusura database", "error", err)
+	}
+	if runErr != nil {
+		log.Fatalf("server error: %v", runErr)
 	}
+	slog.Info("Connessioni al database chiuse")
 }
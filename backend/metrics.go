@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marco-introini/conferenze.tech/backend/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes the application metrics
+const metricsNamespace = "conferenzetech"
+
+// unmatchedRoute labels requests that match no registered route, so that
+// arbitrary paths cannot create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics holds the Prometheus registry of a Server and its HTTP instruments
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+// newMetrics creates a registry with the Go runtime, process and HTTP metrics
+func newMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+	)
+	return m
+}
+
+// CollectDBStats exports the connection pool statistics of sqlDB
+func (s *Server) CollectDBStats(sqlDB *sql.DB) {
+	s.metrics.registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, metricsNamespace))
+}
+
+// metricsMiddleware counts and times requests. The route label is the pattern
+// registered on mux without the method, e.g. /api/conferences/{conference_id}.
+func (m *Metrics) metricsMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := unmatchedRoute
+		if _, pattern := mux.Handler(r); pattern != "" {
+			_, path, found := strings.Cut(pattern, " ")
+			if !found {
+				path = pattern
+			}
+			route = path
+		}
+
+		wrapped := newResponseWriter(w)
+		next.ServeHTTP(wrapped, r)
+
+		status := strconv.Itoa(wrapped.status)
+		m.requests.WithLabelValues(r.Method, route, status).Inc()
+		m.requestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// metricsHandler serves the registry in the Prometheus exposition format.
+// When a token is configured, scrapers must send it as a bearer token.
+func (m *Metrics) metricsHandler(token string) http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelError)})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				unauthorized(w, "Invalid metrics token")
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// conferenceCollector exports registration and carpooling gauges for every
+// upcoming conference, read from the database at scrape time
+type conferenceCollector struct {
+	db      *db.DB
+	timeout time.Duration
+
+	registrations *prometheus.Desc
+	waitlist      *prometheus.Desc
+	ridesOffered  *prometheus.Desc
+	ridesNeeded   *prometheus.Desc
+	scrapeErrors  prometheus.Counter
+}
+
+// newConferenceCollector creates the business metrics collector
+func newConferenceCollector(database *db.DB, timeout time.Duration) *conferenceCollector {
+	labels := []string{"conference_id", "conference"}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "conference", name), help, labels, nil)
+	}
+	return &conferenceCollector{
+		db:            database,
+		timeout:       timeout,
+		registrations: desc("registrations", "Confirmed registrations of an upcoming conference."),
+		waitlist:      desc("waitlist", "Waitlisted registrations of an upcoming conference."),
+		ridesOffered:  desc("rides_offered", "Ride offers for an upcoming conference."),
+		ridesNeeded:   desc("rides_needed", "Open or pending ride requests for an upcoming conference."),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "conference_metrics_errors_total",
+			Help:      "Failed reads of the conference metrics.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *conferenceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.registrations
+	ch <- c.waitlist
+	ch <- c.ridesOffered
+	ch <- c.ridesNeeded
+	c.scrapeErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. A database error is logged and
+// counted; the gauges are then omitted from the scrape.
+func (c *conferenceCollector) Collect(ch chan<- prometheus.Metric) {
+	defer c.scrapeErrors.Collect(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conferences, err := c.db.ListUpcomingConferenceMetrics(ctx)
+	if err != nil {
+		slog.Error("Error collecting conference metrics", "error", err)
+		c.scrapeErrors.Inc()
+		return
+	}
+
+	for _, conf := range conferences {
+		labels := []string{conf.ID.String(), conf.Title}
+		ch <- prometheus.MustNewConstMetric(c.registrations, prometheus.GaugeValue, float64(conf.ConfirmedCount), labels...)
+		ch <- prometheus.MustNewConstMetric(c.waitlist, prometheus.GaugeValue, float64(conf.WaitlistCount), labels...)
+		ch <- prometheus.MustNewConstMetric(c.ridesOffered, prometheus.GaugeValue, float64(conf.RidesOffered), labels...)
+		ch <- prometheus.MustNewConstMetric(c.ridesNeeded, prometheus.GaugeValue, float64(conf.RidesNeeded), labels...)
+	}
+}
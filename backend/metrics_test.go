@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Test etichetta route con il pattern registrato e non con il path della richiesta
+func TestMetricsMiddlewareRouteLabel(t *testing.T) {
+	m := newMetrics()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/conferences/{conference_id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := m.metricsMiddleware(mux, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveRoute(w, r, mux)
+	}))
+
+	for _, path := range []string{"/api/conferences/1", "/api/conferences/2", "/random/path"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/conferences/{conference_id}", "200")); got != 2 {
+		t.Errorf("Expected 2 requests for the conference route, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("GET", unmatchedRoute, "404")); got != 1 {
+		t.Errorf("Expected 1 unmatched request, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.requestDuration); got != 2 {
+		t.Errorf("Expected 2 latency series, got %d", got)
+	}
+}
+
+// Test protezione di /metrics con bearer token
+func TestMetricsHandlerToken(t *testing.T) {
+	m := newMetrics()
+
+	tests := []struct {
+		name   string
+		token  string
+		auth   string
+		status int
+	}{
+		{"No token configured", "", "", http.StatusOK},
+		{"Missing token", "s3cret", "", http.StatusUnauthorized},
+		{"Wrong token", "s3cret", "Bearer wrong", http.StatusUnauthorized},
+		{"Valid token", "s3cret", "Bearer s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rr := httptest.NewRecorder()
+			m.metricsHandler(tt.token).ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusOK && !strings.Contains(rr.Body.String(), "go_goroutines") {
+				t.Error("Expected Go runtime metrics in the response")
+			}
+		})
+	}
+}
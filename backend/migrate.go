@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up               apply all pending migrations
+  down [n]         revert the last n applied migrations (default 1)
+  status           list migrations and whether they have been applied
+  baseline [v]     mark migrations up to version v (default 1) as applied without running them`
+
+// MigrationTimeout bounds the duration of a migrate command
+const MigrationTimeout = 5 * time.Minute
+
+// runMigrateCommand executes the migrate subcommand and writes its report to out
+func runMigrateCommand(sqlDB *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	migrator, err := db.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), MigrationTimeout)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+
+	case "down":
+		steps, err := optionalInt(args[1:], 1)
+		if err != nil || steps <= 0 {
+			return fmt.Errorf("invalid number of steps\n%s", migrateUsage)
+		}
+		reverted, err := migrator.Down(ctx, int(steps))
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+
+	case "baseline":
+		version, err := optionalInt(args[1:], 1)
+		if err != nil || version <= 0 {
+			return fmt.Errorf("invalid baseline version\n%s", migrateUsage)
+		}
+		if err := migrator.Baseline(ctx, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "marked migrations up to %04d as applied\n", version)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
+
+// autoMigrate applies pending migrations at startup
+func autoMigrate(sqlDB *sql.DB) error {
+	migrator, err := db.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), MigrationTimeout)
+	defer cancel()
+
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+	}
+	return err
+}
+
+// optionalInt parses the first argument as an integer, or returns def if there is none
+func optionalInt(args []string, def int64) (int64, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	return strconv.ParseInt(args[0], 10, 64)
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// operation documents a route of the table returned by Server.routes.
+// Legacy aliases are documented by the operation of their successor.
+type operation struct {
+	id          string
+	tag         string
+	summary     string
+	description string
+	query       []parameter
+	headers     []parameter
+	request     any // request body DTO, nil if the route takes no body
+	status      int // success status code
+	response    any // response DTO, nil if the response has no JSON body
+	contentType string
+}
+
+// parameter documents a query parameter or request header
+type parameter struct {
+	name        string
+	typ         string // JSON schema type: string, integer, number or boolean
+	format      string
+	enum        []string
+	required    bool
+	description string
+}
+
+// limitParam is the page size parameter shared by the paginated listings
+var limitParam = parameter{name: "limit", typ: "integer", description: fmt.Sprintf("Page size, 1 to %d (default %d)", MaxPageSize, DefaultPageSize)}
+
+// operations documents every route by pattern. TestOpenAPICoversRoutes fails
+// when a route is registered without an entry here.
+var operations = map[string]operation{
+	"POST /api/v1/register": {id: "register", tag: "Users", summary: "Register a new user and get a token",
+		request: RegisterRequest{}, status: http.StatusCreated, response: RegisterResponse{}},
+	"POST /api/v1/login": {id: "login", tag: "Users", summary: "Log in with email and password",
+		description: "Repeated failures from the same IP for the same email lock the login out with 429 Too Many Requests.",
+		request:     LoginRequest{}, status: http.StatusOK, response: LoginResponse{}},
+	"POST /api/v1/verify-email": {id: "verifyEmail", tag: "Users", summary: "Confirm the email address with the token of the emailed link",
+		request: VerifyEmailRequest{}, status: http.StatusOK, response: UserResponse{}},
+	"POST /api/v1/verify-email/resend": {id: "resendVerificationEmail", tag: "Users", summary: "Email a new verification link to the current user",
+		status: http.StatusNoContent},
+	"POST /api/v1/password/forgot": {id: "forgotPassword", tag: "Users", summary: "Email a password reset link",
+		description: "The response is the same whether the email is registered or not.",
+		request:     ForgotPasswordRequest{}, status: http.StatusAccepted},
+	"POST /api/v1/password/reset": {id: "resetPassword", tag: "Users", summary: "Set a new password with the token of a reset link",
+		description: "The token works once. On success all reset links and all sessions of the user are revoked.",
+		request:     ResetPasswordRequest{}, status: http.StatusNoContent},
+	"GET /api/v1/conferences": {id: "listConferences", tag: "Conferences", summary: "List conferences",
+		description: "Cursor-paginated: follow `next` to get the following page.",
+		query: []parameter{
+			{name: "when", typ: "string", enum: []string{"upcoming", "past"}, description: "Only upcoming or past conferences"},
+			{name: "sort", typ: "string", enum: []string{"date", "-date"}, description: "Sort by date ascending or descending"},
+			{name: "from", typ: "string", description: "Earliest date, RFC 3339 or YYYY-MM-DD"},
+			{name: "to", typ: "string", description: "Latest date, RFC 3339 or YYYY-MM-DD (a plain date covers the whole day)"},
+			{name: "location", typ: "string", description: "Case-insensitive match on the location"},
+			{name: "search", typ: "string", description: "Case-insensitive match on the title"},
+			{name: "cursor", typ: "string", description: "Opaque cursor taken from the next link"},
+			limitParam,
+		},
+		status: http.StatusOK, response: ConferenceListResponse{}},
+	"GET /api/v1/conferences/nearby": {id: "nearbyConferences", tag: "Conferences", summary: "Upcoming conferences near a point, closest first",
+		query: []parameter{
+			{name: "lat", typ: "number", required: true, description: "Latitude, -90 to 90"},
+			{name: "lng", typ: "number", required: true, description: "Longitude, -180 to 180"},
+			{name: "radiusKm", typ: "number", description: fmt.Sprintf("Search radius in km, up to %g (default %g)", MaxNearbyRadiusKm, DefaultNearbyRadiusKm)},
+			limitParam,
+		},
+		status: http.StatusOK, response: []NearbyConferenceResponse{}},
+	"GET /api/v1/conferences/{conference_id}": {id: "getConference", tag: "Conferences", summary: "Get a conference with its attendees",
+		description: "The ETag header must be sent back in If-Match to update the conference.",
+		status:      http.StatusOK, response: ConferenceWithAttendees{}},
+	"GET /api/v1/conferences/{conference_id}/stats": {id: "getConferenceStats", tag: "Conferences", summary: "Registration figures of a conference",
+		status: http.StatusOK, response: ConferenceStatsResponse{}},
+
+	"POST /api/v1/conferences": {id: "createConference", tag: "Conferences", summary: "Create a conference",
+		request: CreateConferenceRequest{}, status: http.StatusCreated, response: ConferenceResponse{}},
+	"PUT /api/v1/conferences/{conference_id}": {id: "updateConference", tag: "Conferences", summary: "Update a conference (organizers only)",
+		headers: []parameter{ifMatchHeader}, request: UpdateConferenceRequest{}, status: http.StatusOK, response: ConferenceResponse{}},
+	"PATCH /api/v1/conferences/{conference_id}": {id: "patchConference", tag: "Conferences", summary: "Update some fields of a conference (organizers only)",
+		headers: []parameter{ifMatchHeader}, request: UpdateConferenceRequest{}, status: http.StatusOK, response: ConferenceResponse{}},
+	"DELETE /api/v1/conferences/{conference_id}": {id: "deleteConference", tag: "Conferences", summary: "Delete a conference (owner only)",
+		status: http.StatusNoContent},
+	"GET /api/v1/conferences/{conference_id}/registrations": {id: "listConferenceRegistrations", tag: "Registrations", summary: "Registrations of a conference (organizers only)",
+		status: http.StatusOK, response: []ConferenceRegistrationResponse{}},
+	"PUT /api/v1/conferences/{conference_id}/organizers/{user_id}": {id: "addOrganizer", tag: "Conferences", summary: "Make a user an organizer (owner only)",
+		status: http.StatusOK, response: ConferenceRegistrationResponse{}},
+	"DELETE /api/v1/conferences/{conference_id}/organizers/{user_id}": {id: "removeOrganizer", tag: "Conferences", summary: "Demote an organizer to attendee (owner only)",
+		status: http.StatusNoContent},
+	"POST /api/v1/conferences/{conference_id}/register": {id: "registerToConference", tag: "Registrations", summary: "Register the current user to a conference",
+		description: "When the conference is full the registration is put on the waitlist.",
+		request:     RegisterToConferenceRequest{}, status: http.StatusCreated, response: db.ConferenceRegistration{}},
+	"GET /api/v1/conferences/{conference_id}/rides": {id: "getConferenceRides", tag: "Rides", summary: "Carpooling overview of a conference",
+		status: http.StatusOK, response: RidesOverviewResponse{}},
+	"POST /api/v1/conferences/{conference_id}/rides/offers": {id: "createRideOffer", tag: "Rides", summary: "Offer a ride to a conference",
+		request: CreateRideOfferRequest{}, status: http.StatusCreated, response: RideOfferResponse{}},
+	"POST /api/v1/conferences/{conference_id}/rides/requests": {id: "createRideRequest", tag: "Rides", summary: "Ask for a ride to a conference",
+		request: CreateRideRequestRequest{}, status: http.StatusCreated, response: RideRequestResponse{}},
+	"GET /api/v1/conferences/{conference_id}/rides/matches": {id: "getRideMatches", tag: "Rides", summary: "Ride offers ranked for the current user's request",
+		status: http.StatusOK, response: []RideMatchResponse{}},
+	"DELETE /api/v1/rides/offers/{offer_id}": {id: "deleteRideOffer", tag: "Rides", summary: "Withdraw a ride offer (driver only)",
+		status: http.StatusNoContent},
+	"GET /api/v1/rides/offers/{offer_id}/requests": {id: "listRideOfferRequests", tag: "Rides", summary: "Passenger requests of a ride offer (driver only)",
+		status: http.StatusOK, response: []RideRequestResponse{}},
+	"POST /api/v1/rides/offers/{offer_id}/join": {id: "joinRideOffer", tag: "Rides", summary: "Ask to join a ride offer",
+		status: http.StatusOK, response: RideRequestResponse{}},
+	"POST /api/v1/rides/requests/{request_id}/accept": {id: "acceptRideRequest", tag: "Rides", summary: "Accept a passenger (driver only)",
+		status: http.StatusOK, response: RideRequestResponse{}},
+	"POST /api/v1/rides/requests/{request_id}/decline": {id: "declineRideRequest", tag: "Rides", summary: "Decline a passenger (driver only)",
+		status: http.StatusOK, response: RideRequestResponse{}},
+	"DELETE /api/v1/rides/requests/{request_id}": {id: "cancelRideRequest", tag: "Rides", summary: "Cancel the current user's ride request",
+		status: http.StatusNoContent},
+	"GET /api/v1/users/registrations": {id: "getUserRegistrations", tag: "Registrations", summary: "Registrations of the current user",
+		status: http.StatusOK, response: []RegistrationResponse{}},
+	"DELETE /api/v1/users/registrations/{conference_id}": {id: "unregisterFromConference", tag: "Registrations", summary: "Cancel the current user's registration",
+		description: "The first waitlisted registrations are promoted to the freed seats.",
+		status:      http.StatusNoContent},
+	"GET /api/v1/users/{user_id}": {id: "getUser", tag: "Users", summary: "Get a user profile",
+		status: http.StatusOK, response: UserResponse{}},
+	"GET /api/v1/me": {id: "getMe", tag: "Users", summary: "Get the current user",
+		status: http.StatusOK, response: UserResponse{}},
+	"PUT /api/v1/me": {id: "updateMe", tag: "Users", summary: "Update the current user's profile",
+		request: UpdateMeRequest{}, status: http.StatusOK, response: UserResponse{}},
+	"PUT /api/v1/me/password": {id: "changePassword", tag: "Users", summary: "Change the current user's password",
+		description: "Requires the current password (403 `invalid_credentials` otherwise). Every session except the current one is revoked.",
+		request:     ChangePasswordRequest{}, status: http.StatusNoContent},
+	"GET /api/v1/tokens": {id: "getTokens", tag: "Tokens", summary: "Sessions of the current user",
+		status: http.StatusOK, response: []TokenResponse{}},
+	"POST /api/v1/tokens/revoke": {id: "revokeToken", tag: "Tokens", summary: "Revoke one of the current user's sessions",
+		query:  []parameter{{name: "id", typ: "string", format: "uuid", required: true, description: "ID of the token to revoke"}},
+		status: http.StatusOK, response: TokenResponse{}},
+	"POST /api/v1/tokens/revoke-others": {id: "revokeOtherTokens", tag: "Tokens", summary: "Revoke all sessions except the current one",
+		status: http.StatusOK, response: RevokeOtherTokensResponse{}},
+	"POST /api/v1/logout": {id: "logout", tag: "Tokens", summary: "Revoke the current session",
+		status: http.StatusNoContent},
+
+	"PUT /api/v1/admin/users/{user_id}/admin": {id: "setUserAdmin", tag: "Admin", summary: "Grant or revoke platform administrator rights",
+		request: SetAdminRequest{}, status: http.StatusOK, response: UserResponse{}},
+
+	"GET /api/openapi.json": {id: "getOpenAPI", tag: "Meta", summary: "This OpenAPI document",
+		status: http.StatusOK, response: map[string]any{}},
+	"GET /api/docs": {id: "getAPIDocs", tag: "Meta", summary: "Interactive API documentation",
+		status: http.StatusOK, contentType: "text/html"},
+	"GET /livez": {id: "livez", tag: "Health", summary: "Liveness: the process is serving HTTP",
+		status: http.StatusOK, response: HealthResponse{}},
+	"GET /health": {id: "health", tag: "Health", summary: "Alias of /livez, kept for existing probes",
+		status: http.StatusOK, response: HealthResponse{}},
+	"GET /readyz": {id: "readyz", tag: "Health", summary: "Readiness: database, migrations and connection pool checks",
+		description: "Returns 503 with the failing checks when the instance should not take traffic.",
+		status:      http.StatusOK, response: HealthResponse{}},
+	"GET /metrics": {id: "metrics", tag: "Health", summary: "Prometheus metrics",
+		description: "Requires `Authorization: Bearer <token>` when a metrics token is configured.",
+		status:      http.StatusOK, contentType: "text/plain"},
+}
+
+// ifMatchHeader carries the ETag of the conference being updated
+var ifMatchHeader = parameter{name: "If-Match", typ: "string", required: true, description: "ETag returned by getConference, or *"}
+
+// pathParamRe matches the wildcards of a route pattern
+var pathParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// buildOpenAPI builds the OpenAPI 3.1 document of routes and of their legacy
+// aliases, which reuse the operation of their successor marked as deprecated.
+// It fails if a route has no entry in operations.
+func buildOpenAPI(routes []route, aliases []legacyRoute) (map[string]any, error) {
+	b := &specBuilder{schemas: &schemaBuilder{components: map[string]any{}}, paths: map[string]map[string]any{}}
+	b.errorRef = b.schemas.schema(reflect.TypeOf(ErrorResponse{}))
+
+	var undocumented []string
+	for _, rt := range routes {
+		op, ok := operations[rt.pattern]
+		if !ok {
+			undocumented = append(undocumented, rt.pattern)
+			continue
+		}
+		b.add(rt, op, false)
+	}
+	for _, alias := range aliases {
+		op, ok := operations[alias.successor]
+		if !ok {
+			undocumented = append(undocumented, alias.pattern)
+			continue
+		}
+		op.id += "Legacy"
+		op.description = strings.TrimSpace(fmt.Sprintf("Deprecated alias of `%s`, removed on %s. %s",
+			alias.successor, legacySunset.Format(time.DateOnly), op.description))
+		b.add(alias.route, op, true)
+	}
+	if len(undocumented) > 0 {
+		return nil, fmt.Errorf("routes without OpenAPI operation: %s", strings.Join(undocumented, ", "))
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "conferenze.tech API",
+			"version":     "1.0.0",
+			"description": "Errors are returned as ErrorResponse with a stable `code`; see API.md for the list of codes.",
+		},
+		"paths": b.paths,
+		"components": map[string]any{
+			"schemas": b.schemas.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}, nil
+}
+
+// specBuilder collects the paths of the OpenAPI document
+type specBuilder struct {
+	schemas  *schemaBuilder
+	errorRef map[string]any
+	paths    map[string]map[string]any
+}
+
+// add adds the operation op of route rt to the document
+func (b *specBuilder) add(rt route, op operation, deprecated bool) {
+	method, path, _ := strings.Cut(rt.pattern, " ")
+
+	var params []any
+	for _, match := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]any{
+			"name": match[1], "in": "path", "required": true,
+			"schema": map[string]any{"type": "string", "format": "uuid"},
+		})
+	}
+	for _, p := range op.query {
+		params = append(params, p.openAPI("query"))
+	}
+	for _, p := range op.headers {
+		params = append(params, p.openAPI("header"))
+	}
+
+	success := map[string]any{"description": http.StatusText(op.status)}
+	switch {
+	case op.response != nil:
+		success["content"] = map[string]any{"application/json": map[string]any{"schema": b.schemas.schema(reflect.TypeOf(op.response))}}
+	case op.contentType != "":
+		success["content"] = map[string]any{op.contentType: map[string]any{"schema": map[string]any{"type": "string"}}}
+	}
+
+	spec := map[string]any{
+		"operationId": op.id,
+		"summary":     op.summary,
+		"tags":        []string{op.tag},
+		"responses": map[string]any{
+			strconv.Itoa(op.status): success,
+			"default": map[string]any{
+				"description": "Error",
+				"content":     map[string]any{"application/json": map[string]any{"schema": b.errorRef}},
+			},
+		},
+	}
+	if rt.access == accessVerified {
+		op.description = strings.TrimSpace(op.description + " Requires a verified email address (403 `email_not_verified` otherwise).")
+	}
+	if op.description != "" {
+		spec["description"] = op.description
+	}
+	if len(params) > 0 {
+		spec["parameters"] = params
+	}
+	if op.request != nil {
+		spec["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": b.schemas.schema(reflect.TypeOf(op.request))}},
+		}
+	}
+	if rt.access != accessPublic {
+		spec["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+	}
+	if deprecated {
+		spec["deprecated"] = true
+	}
+
+	if b.paths[path] == nil {
+		b.paths[path] = map[string]any{}
+	}
+	b.paths[path][strings.ToLower(method)] = spec
+}
+
+// openAPI returns the OpenAPI parameter object
+func (p parameter) openAPI(in string) map[string]any {
+	schema := map[string]any{"type": p.typ}
+	if p.format != "" {
+		schema["format"] = p.format
+	}
+	if len(p.enum) > 0 {
+		schema["enum"] = p.enum
+	}
+	param := map[string]any{"name": p.name, "in": in, "schema": schema, "description": p.description}
+	if p.required {
+		param["required"] = true
+	}
+	return param
+}
+
+// schemaBuilder derives JSON schemas from Go types, collecting structs in components
+type schemaBuilder struct {
+	components map[string]any
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// schema returns the schema of t; structs are referenced from components
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(b.schema(t.Elem()))
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = nil // placeholder for recursive types
+			properties, required := map[string]any{}, []string{}
+			b.addFields(t, properties, &required)
+			schema := map[string]any{"type": "object", "properties": properties}
+			if len(required) > 0 {
+				slices.Sort(required)
+				schema["required"] = required
+			}
+			b.components[t.Name()] = schema
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// addFields adds the JSON fields of struct t, flattening embedded structs
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			b.addFields(f.Type, properties, required)
+			continue
+		}
+
+		name := jsonName(f)
+		schema := b.schema(f.Type)
+		rules := f.Tag.Get("validate")
+		applyRules(schema, rules)
+		properties[name] = schema
+
+		// Requests: fields validated as required. Responses: fields always present.
+		if strings.Contains(","+rules+",", ",required,") ||
+			(rules == "" && f.Type.Kind() != reflect.Pointer && !strings.Contains(opts, "omitempty")) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applyRules maps the validate rules of validate.go to JSON schema keywords
+func applyRules(schema map[string]any, rules string) {
+	if rules == "" {
+		return
+	}
+	str := schemaHasType(schema, "string")
+	for _, rule := range strings.Split(rules, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+		n, _ := strconv.ParseFloat(arg, 64)
+		switch {
+		case rule == "min" && str:
+			schema["minLength"] = int(n)
+		case rule == "max" && str:
+			schema["maxLength"] = int(n)
+		case rule == "min":
+			schema["minimum"] = n
+		case rule == "max":
+			schema["maximum"] = n
+		case rule == "email":
+			schema["format"] = "email"
+		case rule == "url":
+			schema["format"] = "uri"
+		case rule == "rfc3339":
+			schema["format"] = "date-time"
+		case rule == "oneof":
+			schema["enum"] = strings.Fields(arg)
+		case rule == "role":
+			schema["enum"] = ValidRoles
+		case rule == "password":
+			schema["minLength"] = minPasswordLength
+			schema["format"] = "password"
+		}
+	}
+}
+
+// nullable allows null in addition to schema
+func nullable(schema map[string]any) map[string]any {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+	return map[string]any{"oneOf": []any{schema, map[string]any{"type": "null"}}}
+}
+
+// schemaHasType reports whether schema accepts the JSON type typ
+func schemaHasType(schema map[string]any, typ string) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == typ
+	case []string:
+		return slices.Contains(t, typ)
+	}
+	return false
+}
+
+// OpenAPISpec serves the OpenAPI document of the API
+func (s *Server) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	doc, err := buildOpenAPI(s.routes(), s.legacyRoutes())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error building OpenAPI document", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode OpenAPI document", "error", err)
+	}
+}
+
+//go:embed openapi.html
+var apiDocsPage []byte
+
+// apiDocsPolicy relaxes the API Content-Security-Policy for the documentation
+// page, which loads Redoc from its CDN and runs it in a web worker
+const apiDocsPolicy = "default-src 'none'; script-src https://cdn.jsdelivr.net; style-src 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src https://fonts.gstatic.com; img-src 'self' data: https://cdn.redoc.ly; connect-src 'self'; worker-src blob:; " +
+	"frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+
+// APIDocs serves the Redoc page rendering /api/openapi.json
+func (s *Server) APIDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", apiDocsPolicy)
+	if _, err := w.Write(apiDocsPage); err != nil {
+		slog.WarnContext(r.Context(), "Failed to write API docs page", "error", err)
+	}
+}
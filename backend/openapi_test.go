@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test copertura della specifica: ogni route registrata deve essere documentata
+func TestOpenAPICoversRoutes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Metrics.Enabled = true
+	s := NewServer(nil, cfg)
+	routes := s.routes()
+
+	registered := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		registered[rt.pattern] = true
+		if _, ok := operations[rt.pattern]; !ok {
+			t.Errorf("Route %s has no OpenAPI operation", rt.pattern)
+		}
+	}
+	for pattern := range operations {
+		if !registered[pattern] {
+			t.Errorf("OpenAPI operation %s does not match any route", pattern)
+		}
+	}
+
+	aliases := s.legacyRoutes()
+	doc, err := buildOpenAPI(routes, aliases)
+	if err != nil {
+		t.Fatalf("Failed to build OpenAPI document: %v", err)
+	}
+	paths := doc["paths"].(map[string]map[string]any)
+	ids := map[string]string{}
+	for _, alias := range aliases {
+		routes = append(routes, alias.route)
+	}
+	for _, rt := range routes {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		op, ok := paths[path][strings.ToLower(method)].(map[string]any)
+		if !ok {
+			t.Errorf("Route %s missing from the document", rt.pattern)
+			continue
+		}
+		id := op["operationId"].(string)
+		if other, dup := ids[id]; dup {
+			t.Errorf("Duplicate operationId %s for %s and %s", id, other, rt.pattern)
+		}
+		ids[id] = rt.pattern
+		if _, secured := op["security"]; secured != (rt.access != accessPublic) {
+			t.Errorf("Route %s: security requirement does not match access level", rt.pattern)
+		}
+		if _, deprecated := op["deprecated"]; deprecated != strings.HasSuffix(id, "Legacy") {
+			t.Errorf("Route %s: only legacy aliases must be deprecated", rt.pattern)
+		}
+	}
+}
+
+// Test fallimento per route non documentate
+func TestOpenAPIUndocumentedRoute(t *testing.T) {
+	routes := []route{{pattern: "GET /api/undocumented", access: accessPublic}}
+	if _, err := buildOpenAPI(routes, nil); err == nil || !strings.Contains(err.Error(), "GET /api/undocumented") {
+		t.Errorf("Expected error naming the undocumented route, got %v", err)
+	}
+}
+
+// Test schemi derivati dai DTO e dai tag validate
+func TestOpenAPISchemas(t *testing.T) {
+	s := NewServer(nil, DefaultConfig())
+	doc, err := buildOpenAPI(s.routes(), s.legacyRoutes())
+	if err != nil {
+		t.Fatalf("Failed to build OpenAPI document: %v", err)
+	}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	register, ok := schemas["RegisterRequest"].(map[string]any)
+	if !ok {
+		t.Fatal("Expected RegisterRequest schema")
+	}
+	if got := register["required"]; !equalStrings(got, []string{"email", "name", "password"}) {
+		t.Errorf("Expected required email, name, password, got %v", got)
+	}
+	props := register["properties"].(map[string]any)
+	if email := props["email"].(map[string]any); email["format"] != "email" || email["maxLength"] != 255 {
+		t.Errorf("Expected email format with maxLength 255, got %v", email)
+	}
+	if nickname := props["nickname"].(map[string]any); !equalStrings(nickname["type"], []string{"string", "null"}) {
+		t.Errorf("Expected nullable nickname, got %v", nickname)
+	}
+
+	// La regola role espone i ruoli ammessi come enum
+	role := schemas["RegisterToConferenceRequest"].(map[string]any)["properties"].(map[string]any)["role"].(map[string]any)
+	if !equalStrings(role["enum"], ValidRoles) {
+		t.Errorf("Expected role enum %v, got %v", ValidRoles, role["enum"])
+	}
+
+	// I DTO di risposta ereditano i campi delle struct embedded
+	if _, ok := schemas["ConferenceWithAttendees"].(map[string]any)["properties"].(map[string]any)["title"]; !ok {
+		t.Error("Expected ConferenceWithAttendees to include the embedded conference fields")
+	}
+	if _, ok := schemas["ErrorResponse"]; !ok {
+		t.Error("Expected ErrorResponse schema")
+	}
+}
+
+func equalStrings(got any, want []string) bool {
+	s, ok := got.([]string)
+	return ok && strings.Join(s, ",") == strings.Join(want, ",")
+}
+
+// Test endpoint della specifica e della pagina di documentazione
+func TestOpenAPIEndpoints(t *testing.T) {
+	handler := NewServer(nil, DefaultConfig()).Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Paths["/api/v1/conferences/{conference_id}"] == nil {
+		t.Errorf("Unexpected document: %+v", doc)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/docs", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/api/openapi.json") {
+		t.Errorf("Expected docs page referencing the spec, got %d", rr.Code)
+	}
+	if csp := rr.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "https://cdn.jsdelivr.net") {
+		t.Errorf("Expected CSP allowing the Redoc CDN, got %q", csp)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errInvalidCursor is returned when a pagination cursor cannot be decoded
+var errInvalidCursor = errors.New("Invalid cursor")
+
+// encodeCursor builds an opaque keyset cursor from the sort key and id of the last item of a page
+func encodeCursor(t time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	ts, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	return t, id, nil
+}
+
+// parseLimit reads the page size from the limit query parameter
+func parseLimit(values url.Values) (int32, error) {
+	raw := values.Get("limit")
+	if raw == "" {
+		return DefaultPageSize, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > MaxPageSize {
+		return 0, errors.New("Limit must be between 1 and " + strconv.Itoa(MaxPageSize))
+	}
+	return int32(limit), nil
+}
+
+// nextLink returns the URL of the next page: the same path and query with the cursor replaced
+func nextLink(u *url.URL, cursor string) string {
+	values := u.Query()
+	values.Set("cursor", cursor)
+	return u.Path + "?" + values.Encode()
+}
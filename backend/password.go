@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// errResetTokenExpired is returned from the reset transaction when the token is past its expiry
+var errResetTokenExpired = errors.New("password reset token expired")
+
+// checkResetToken returns errResetTokenExpired if token is past its expiry
+func checkResetToken(token db.PasswordResetToken) error {
+	if !time.Now().Before(token.ExpiresAt) {
+		return errResetTokenExpired
+	}
+	return nil
+}
+
+// writeResetTokenError maps the errors of a reset token lookup to HTTP responses
+func writeResetTokenError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusBadRequest, CodeInvalidResetToken, "Invalid or already used reset link")
+	case errors.Is(err, errResetTokenExpired):
+		writeError(w, http.StatusBadRequest, CodeResetTokenExpired, "Reset link expired, request a new one")
+	default:
+		slog.ErrorContext(r.Context(), "Error resetting password", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to reset password")
+	}
+}
+
+// sendPasswordResetEmail stores a new reset token for user and emails the link using it.
+// Like authentication tokens, only the hash of the token is stored.
+func (s *Server) sendPasswordResetEmail(ctx context.Context, user db.User) error {
+	token, err := generateToken(s.cfg.Tokens.Size)
+	if err != nil {
+		return fmt.Errorf("generating reset token: %w", err)
+	}
+
+	expires := time.Now().Add(s.cfg.Accounts.ResetTTL)
+	if _, err := s.db.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expires,
+	}); err != nil {
+		return fmt.Errorf("saving reset token: %w", err)
+	}
+
+	return s.mailer.Send(ctx, Message{
+		To:      user.Email,
+		Subject: "Reimposta la password di conferenze.tech",
+		Body: fmt.Sprintf("Ciao %s,\n\n"+
+			"abbiamo ricevuto una richiesta di reimpostare la password del tuo account su conferenze.tech. "+
+			"Per scegliere una nuova password apri questo link:\n\n"+
+			"%s\n\n"+
+			"Il link può essere usato una sola volta e scade il %s. "+
+			"Se non hai chiesto tu di reimpostare la password, ignora questa email: la password attuale resta valida.\n",
+			user.Name, accountLink(s.cfg.Accounts.ResetURL, token), expires.Format("02/01/2006 15:04 MST")),
+	})
+}
+
+// ForgotPassword emails a password reset link to the account with the given email.
+// The response is the same whether the email is registered or not, and the link is
+// sent in the background so it takes the same time too: it cannot be used to find
+// out which addresses have an account.
+func (s *Server) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	var req ForgotPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if !s.limitAuthAttempt(w, r, req.Email) {
+		return
+	}
+
+	user, err := s.db.GetUserByEmail(ctx, req.Email)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Nothing to send, but the client must not be able to tell
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	default:
+		s.goBackground(r, func(ctx context.Context) {
+			if err := s.sendPasswordResetEmail(ctx, user); err != nil {
+				slog.ErrorContext(ctx, "Error sending password reset email", "error", err)
+			}
+		})
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword sets a new password with the token of a reset link. The token
+// works once; on success every reset link of the user is invalidated and all
+// sessions are revoked, since the old password may have been compromised.
+func (s *Server) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	var req ResetPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if !s.limitAuthIP(w, r) {
+		return
+	}
+
+	// Hashing is slow: only valid tokens get to it, and it is done before the
+	// transaction is opened. The transaction checks the token again.
+	token, err := s.db.GetPasswordResetToken(ctx, hashToken(req.Token))
+	if err == nil {
+		err = checkResetToken(token)
+	}
+	if err != nil {
+		writeResetTokenError(w, r, err)
+		return
+	}
+
+	passwordHash, err := db.HashPassword(req.Password)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to hash password")
+		return
+	}
+
+	var user db.User
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		token, err := q.UsePasswordResetToken(ctx, hashToken(req.Token))
+		if err != nil {
+			return err
+		}
+		if err := checkResetToken(token); err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:       token.UserID,
+			Password: passwordHash,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		_, err = q.RevokeAllUserTokens(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		writeResetTokenError(w, r, err)
+		return
+	}
+
+	// Failed logins before the reset must not keep the owner locked out
+	s.resetLoginFailures(r, user.Email)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword replaces the password of the authenticated user, who must
+// confirm the current one. The session used for the request stays valid, all
+// the others are revoked. Wrong current passwords count as failed logins.
+func (s *Server) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	tokenID, ok := contextTokenID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusNotFound, CodeUserNotFound, "User not found")
+		return
+	}
+
+	if !s.checkLoginLockout(w, r, user.Email) {
+		return
+	}
+	if !db.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		s.recordLoginFailure(r, user.Email)
+		writeError(w, http.StatusForbidden, CodeInvalidCredentials, "Current password is incorrect")
+		return
+	}
+	s.resetLoginFailures(r, user.Email)
+
+	passwordHash, err := db.HashPassword(req.NewPassword)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to hash password")
+		return
+	}
+
+	err = s.db.WithTransaction(ctx, func(q db.Querier) error {
+		if _, err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:       user.ID,
+			Password: passwordHash,
+		}); err != nil {
+			return err
+		}
+		if err := q.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		_, err := q.RevokeOtherUserTokens(ctx, db.RevokeOtherUserTokensParams{
+			UserID: user.ID,
+			ID:     tokenID,
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error changing password", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test rifiuto delle richieste di password non valide prima di accedere al database
+func TestPasswordEndpointsValidation(t *testing.T) {
+	handler := NewServer(nil, DefaultConfig()).Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"Forgot without email", "POST", "/api/v1/password/forgot", `{}`, http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"Forgot with invalid email", "POST", "/api/v1/password/forgot", `{"email":"not-an-email"}`, http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"Reset without token", "POST", "/api/v1/password/reset", `{"password":"new-password-1"}`, http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"Reset with weak password", "POST", "/api/v1/password/reset", `{"token":"abc","password":"short"}`, http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"Reset with unknown field", "POST", "/api/v1/password/reset", `{"token":"abc","password":"new-password-1","email":"a@b.it"}`, http.StatusBadRequest, CodeInvalidBody},
+		{"Change without authentication", "PUT", "/api/v1/me/password", `{"currentPassword":"old","newPassword":"new-password-1"}`, http.StatusUnauthorized, CodeUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tt.code+`"`) {
+				t.Errorf("Expected code %s, got %s", tt.code, rr.Body.String())
+			}
+		})
+	}
+}
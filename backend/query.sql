@@ -10,13 +10,13 @@ DELETE FROM users;
 -- name: CreateUser :one
 INSERT INTO users (email, password, name, nickname, city, avatar_url, bio)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at;
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at;
 
 -- name: GetUserByID :one
-SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at FROM users WHERE id = $1;
+SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at FROM users WHERE id = $1;
 
 -- name: GetUserByEmail :one
-SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at FROM users WHERE email = $1;
+SELECT id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at FROM users WHERE email = $1;
 
 -- name: UpdateUser :one
 UPDATE users SET
@@ -27,48 +27,88 @@ UPDATE users SET
     bio = COALESCE(sqlc.narg('bio'), bio),
     updated_at = NOW()
 WHERE id = sqlc.arg('id')
-RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at;
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at;
+
+-- name: SetUserAdmin :one
+UPDATE users SET is_admin = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at;
 
 -- name: UpdateUserPassword :one
 UPDATE users SET password = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at;
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at;
+
+-- name: VerifyUserEmail :one
+UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING id, email, password, name, nickname, city, avatar_url, bio, created_at, updated_at, is_admin, email_verified_at;
 
 -- name: CreateConference :one
-INSERT INTO conferences (title, date, location, website, latitude, longitude, created_by)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at;
+INSERT INTO conferences (title, date, location, website, latitude, longitude, created_by, capacity)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity;
 
 -- name: GetConferenceByID :one
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences WHERE id = $1;
+SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity FROM conferences WHERE id = $1;
 
+-- Filters are optional; the cursor is the (date, id) of the last row of the previous page
 -- name: ListConferences :many
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences ORDER BY date DESC;
+SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity
+FROM conferences
+WHERE (sqlc.narg('upcoming')::boolean IS NULL OR (date >= NOW()) = sqlc.narg('upcoming')::boolean)
+  AND (sqlc.narg('from_date')::timestamptz IS NULL OR date >= sqlc.narg('from_date')::timestamptz)
+  AND (sqlc.narg('to_date')::timestamptz IS NULL OR date <= sqlc.narg('to_date')::timestamptz)
+  AND (sqlc.narg('location')::text IS NULL OR location ILIKE '%' || sqlc.narg('location')::text || '%')
+  AND (sqlc.narg('search')::text IS NULL OR to_tsvector('simple', title || ' ' || location) @@ websearch_to_tsquery('simple', sqlc.narg('search')::text))
+  AND (sqlc.narg('cursor_date')::timestamptz IS NULL
+       OR (sqlc.arg('descending')::boolean AND (date, id) < (sqlc.narg('cursor_date')::timestamptz, sqlc.narg('cursor_id')::uuid))
+       OR (NOT sqlc.arg('descending')::boolean AND (date, id) > (sqlc.narg('cursor_date')::timestamptz, sqlc.narg('cursor_id')::uuid)))
+ORDER BY
+    CASE WHEN sqlc.arg('descending')::boolean THEN date END DESC,
+    CASE WHEN sqlc.arg('descending')::boolean THEN id END DESC,
+    date ASC,
+    id ASC
+LIMIT sqlc.arg('page_limit');
 
--- name: ListUpcomingConferences :many
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences WHERE date >= NOW() ORDER BY date ASC;
-
--- name: ListConferencesByLocation :many
-SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at FROM conferences WHERE location ILIKE $1 ORDER BY date DESC;
+-- The bounding box prefilter uses idx_conferences_coordinates; the haversine distance is exact
+-- name: ListConferencesNearby :many
+SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity, distance_km
+FROM (
+    SELECT id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity,
+           (2 * 6371 * asin(sqrt(
+               power(sin(radians(latitude - sqlc.arg('lat')::float8) / 2), 2) +
+               cos(radians(sqlc.arg('lat')::float8)) * cos(radians(latitude)) *
+               power(sin(radians(longitude - sqlc.arg('lng')::float8) / 2), 2)
+           )))::float8 AS distance_km
+    FROM conferences
+    WHERE latitude BETWEEN sqlc.arg('min_lat')::float8 AND sqlc.arg('max_lat')::float8
+      AND longitude BETWEEN sqlc.arg('min_lng')::float8 AND sqlc.arg('max_lng')::float8
+) nearby
+WHERE distance_km <= sqlc.arg('radius_km')::float8
+ORDER BY distance_km ASC, date ASC
+LIMIT sqlc.arg('page_limit');
 
 -- name: UpdateConference :one
 UPDATE conferences SET
-    title = COALESCE($2, title),
-    date = COALESCE($3, date),
-    location = COALESCE($4, location),
-    website = COALESCE($5, website),
-    latitude = COALESCE($6, latitude),
-    longitude = COALESCE($7, longitude),
+    title = COALESCE(sqlc.narg('title'), title),
+    date = COALESCE(sqlc.narg('date'), date),
+    location = COALESCE(sqlc.narg('location'), location),
+    website = CASE WHEN sqlc.arg('clear_website')::boolean THEN NULL ELSE COALESCE(sqlc.narg('website'), website) END,
+    latitude = CASE WHEN sqlc.arg('clear_coordinates')::boolean THEN NULL ELSE COALESCE(sqlc.narg('latitude'), latitude) END,
+    longitude = CASE WHEN sqlc.arg('clear_coordinates')::boolean THEN NULL ELSE COALESCE(sqlc.narg('longitude'), longitude) END,
+    capacity = CASE WHEN sqlc.arg('clear_capacity')::boolean THEN NULL ELSE COALESCE(sqlc.narg('capacity'), capacity) END,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at;
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('if_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('if_updated_at')::timestamptz)
+RETURNING id, title, date, location, website, latitude, longitude, created_by, created_at, updated_at, capacity;
 
 -- name: DeleteConference :exec
 DELETE FROM conferences WHERE id = $1;
 
 -- name: RegisterUserToConference :one
-INSERT INTO conference_registrations (user_id, conference_id, role, notes, needs_ride, has_car)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO conference_registrations (user_id, conference_id, role, notes, needs_ride, has_car, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at;
 
 -- name: GetRegistration :one
@@ -79,20 +119,34 @@ SELECT r.id, r.user_id, r.conference_id, r.status, r.role, r.notes, r.needs_ride
        u.email, u.name, u.nickname, u.city, u.avatar_url
 FROM conference_registrations r
 JOIN users u ON u.id = r.user_id
-WHERE r.conference_id = $1;
+WHERE r.conference_id = sqlc.arg('conference_id')
+  AND (sqlc.arg('include_cancelled')::boolean OR r.status != 'cancelled')
+ORDER BY r.registered_at ASC;
 
 -- name: GetRegistrationsByUser :many
 SELECT r.id, r.user_id, r.conference_id, r.status, r.role, r.notes, r.needs_ride, r.has_car, r.registered_at, r.cancelled_at,
        c.title, c.date, c.location, c.website
 FROM conference_registrations r
 JOIN conferences c ON c.id = r.conference_id
-WHERE r.user_id = $1;
+WHERE r.user_id = $1 AND r.status != 'cancelled';
 
 -- name: UpdateRegistrationStatus :one
 UPDATE conference_registrations SET status = $2
 WHERE id = $1
 RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at;
 
+-- name: ReactivateRegistration :one
+UPDATE conference_registrations SET
+    status = $2,
+    role = $3,
+    notes = $4,
+    needs_ride = $5,
+    has_car = $6,
+    registered_at = NOW(),
+    cancelled_at = NULL
+WHERE id = $1
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at;
+
 -- name: CancelRegistration :one
 UPDATE conference_registrations SET status = 'cancelled', cancelled_at = NOW()
 WHERE id = $1
@@ -101,6 +155,49 @@ RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car,
 -- name: DeleteRegistration :exec
 DELETE FROM conference_registrations WHERE user_id = $1 AND conference_id = $2;
 
+-- name: GetConferenceAccess :one
+SELECT
+    u.is_admin,
+    (c.created_by = u.id)::boolean AS is_owner,
+    COALESCE(r.role = 'organizer' AND r.status != 'cancelled', FALSE)::boolean AS is_organizer
+FROM users u
+CROSS JOIN conferences c
+LEFT JOIN conference_registrations r ON r.conference_id = c.id AND r.user_id = u.id
+WHERE u.id = sqlc.arg('user_id') AND c.id = sqlc.arg('conference_id');
+
+-- Active registrations keep their status; new and cancelled ones take the status computed from the capacity
+-- name: UpsertConferenceOrganizer :one
+INSERT INTO conference_registrations (user_id, conference_id, role, status)
+VALUES ($1, $2, 'organizer', $3)
+ON CONFLICT (user_id, conference_id) DO UPDATE SET
+    role = 'organizer',
+    status = CASE WHEN conference_registrations.status = 'cancelled' THEN EXCLUDED.status ELSE conference_registrations.status END,
+    registered_at = CASE WHEN conference_registrations.status = 'cancelled' THEN NOW() ELSE conference_registrations.registered_at END,
+    cancelled_at = NULL
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at;
+
+-- name: RemoveConferenceOrganizer :one
+UPDATE conference_registrations SET role = 'attendee'
+WHERE user_id = $1 AND conference_id = $2 AND role = 'organizer'
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at;
+
+-- name: LockConferenceCapacity :one
+SELECT capacity FROM conferences WHERE id = $1 FOR UPDATE;
+
+-- name: CountConfirmedRegistrations :one
+SELECT COUNT(*) FROM conference_registrations
+WHERE conference_id = $1 AND status IN ('registered', 'attended');
+
+-- name: PromoteNextWaitlisted :one
+UPDATE conference_registrations SET status = 'registered'
+WHERE id = (
+    SELECT w.id FROM conference_registrations w
+    WHERE w.conference_id = $1 AND w.status = 'waitlist'
+    ORDER BY w.registered_at ASC
+    LIMIT 1
+)
+RETURNING id, user_id, conference_id, status, role, notes, needs_ride, has_car, registered_at, cancelled_at;
+
 -- name: ListUsersNeedingRide :many
 SELECT u.id, u.email, u.password, u.name, u.nickname, u.city, u.avatar_url, u.bio, u.created_at, u.updated_at,
        c.title, c.location, r.notes
@@ -119,17 +216,32 @@ WHERE r.conference_id = $1 AND r.has_car = TRUE AND r.status != 'cancelled';
 
 -- name: GetConferenceStats :one
 SELECT
-    c.id, c.title,
-    COUNT(r.id) as total_registrations,
+    c.id, c.title, c.capacity,
+    COUNT(r.id) FILTER (WHERE r.status != 'cancelled') as total_registrations,
     COUNT(r.id) FILTER (WHERE r.status = 'registered') as confirmed_count,
+    COUNT(r.id) FILTER (WHERE r.status = 'waitlist') as waitlist_count,
+    COUNT(r.id) FILTER (WHERE r.status = 'cancelled') as cancelled_count,
     COUNT(r.id) FILTER (WHERE r.needs_ride = TRUE AND r.status != 'cancelled') as needing_ride_count,
     COUNT(r.id) FILTER (WHERE r.has_car = TRUE AND r.status != 'cancelled') as offering_ride_count
 FROM conferences c
 LEFT JOIN conference_registrations r ON r.conference_id = c.id
 WHERE c.id = $1
-GROUP BY c.id, c.title;
+GROUP BY c.id, c.title, c.capacity;
+
+-- name: ListUpcomingConferenceMetrics :many
+SELECT
+    c.id, c.title,
+    COUNT(r.id) FILTER (WHERE r.status = 'registered') as confirmed_count,
+    COUNT(r.id) FILTER (WHERE r.status = 'waitlist') as waitlist_count,
+    (SELECT COUNT(*) FROM ride_offers o WHERE o.conference_id = c.id) as rides_offered,
+    (SELECT COUNT(*) FROM ride_requests q WHERE q.conference_id = c.id AND q.status IN ('open', 'pending')) as rides_needed
+FROM conferences c
+LEFT JOIN conference_registrations r ON r.conference_id = c.id
+WHERE c.date >= NOW()
+GROUP BY c.id, c.title
+ORDER BY c.date ASC;
 
--- Token management queries (user_tokens table must be present in schema.sql)
+-- Token management queries (user_tokens table is created by the baseline migration)
 -- name: CreateToken :one
 INSERT INTO user_tokens (user_id, token_hash)
 VALUES ($1, $2)
@@ -151,3 +263,159 @@ DELETE FROM user_tokens WHERE id = $1;
 
 -- name: RevokeToken :one
 UPDATE user_tokens SET revoked = true WHERE id = $1 RETURNING id, user_id, token_hash, created_at, last_used_at, revoked;
+
+-- name: TouchToken :exec
+UPDATE user_tokens SET last_used_at = NOW() WHERE id = $1;
+
+-- name: DeleteExpiredTokens :execrows
+DELETE FROM user_tokens
+WHERE revoked = TRUE
+   OR created_at < sqlc.arg('created_before')::timestamptz
+   OR COALESCE(last_used_at, created_at) < sqlc.arg('used_before')::timestamptz;
+
+-- name: RevokeUserToken :one
+UPDATE user_tokens SET revoked = true
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, token_hash, created_at, last_used_at, revoked;
+
+-- name: RevokeOtherUserTokens :execrows
+UPDATE user_tokens SET revoked = true
+WHERE user_id = $1 AND id != $2 AND revoked = FALSE;
+
+-- name: RevokeAllUserTokens :execrows
+UPDATE user_tokens SET revoked = true
+WHERE user_id = $1 AND revoked = FALSE;
+
+-- Password reset queries
+
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, created_at, expires_at, used_at;
+
+-- Read-only check of an unused token, done before the password is hashed
+-- name: GetPasswordResetToken :one
+SELECT id, user_id, token_hash, created_at, expires_at, used_at
+FROM password_reset_tokens
+WHERE token_hash = $1 AND used_at IS NULL;
+
+-- Marks the token as used: a token already used returns no rows, so it works once
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL
+RETURNING id, user_id, token_hash, created_at, expires_at, used_at;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteExpiredPasswordResetTokens :execrows
+DELETE FROM password_reset_tokens
+WHERE used_at IS NOT NULL OR expires_at < NOW();
+
+-- Carpooling queries
+
+-- An existing offer by the same driver makes the insert return no rows
+-- name: CreateRideOffer :one
+INSERT INTO ride_offers (conference_id, driver_id, seats, departure_city, departure_time, departure_latitude, departure_longitude, notes)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (conference_id, driver_id) DO NOTHING
+RETURNING id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at;
+
+-- name: GetRideOffer :one
+SELECT id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at
+FROM ride_offers
+WHERE id = $1;
+
+-- name: ListRideOffersByConference :many
+SELECT o.id, o.conference_id, o.driver_id, o.seats, o.seats_taken, o.departure_city, o.departure_time, o.departure_latitude, o.departure_longitude, o.notes, o.created_at,
+       u.email, u.name, u.nickname, u.city
+FROM ride_offers o
+JOIN users u ON u.id = o.driver_id
+JOIN conference_registrations cr ON cr.conference_id = o.conference_id AND cr.user_id = o.driver_id AND cr.status != 'cancelled'
+WHERE o.conference_id = $1
+ORDER BY o.departure_time ASC;
+
+-- name: GetRideOfferByDriver :one
+SELECT id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at
+FROM ride_offers
+WHERE conference_id = $1 AND driver_id = $2;
+
+-- name: DeleteRideOffer :exec
+DELETE FROM ride_offers WHERE id = $1;
+
+-- name: ReserveRideSeat :one
+UPDATE ride_offers SET seats_taken = seats_taken + 1
+WHERE id = $1 AND seats_taken < seats
+RETURNING id, conference_id, driver_id, seats, seats_taken, departure_city, departure_time, departure_latitude, departure_longitude, notes, created_at;
+
+-- name: ReleaseRideSeat :exec
+UPDATE ride_offers SET seats_taken = seats_taken - 1
+WHERE id = $1 AND seats_taken > 0;
+
+-- A cancelled request is reopened in place; an active one makes the insert return no rows
+-- name: CreateRideRequest :one
+INSERT INTO ride_requests (conference_id, passenger_id, pickup_city, pickup_latitude, pickup_longitude, notes)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (conference_id, passenger_id) DO UPDATE SET
+    status = 'open',
+    offer_id = NULL,
+    pickup_city = EXCLUDED.pickup_city,
+    pickup_latitude = EXCLUDED.pickup_latitude,
+    pickup_longitude = EXCLUDED.pickup_longitude,
+    notes = EXCLUDED.notes,
+    updated_at = NOW()
+WHERE ride_requests.status = 'cancelled'
+RETURNING id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at;
+
+-- name: GetRideRequestByPassenger :one
+SELECT id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at
+FROM ride_requests
+WHERE conference_id = $1 AND passenger_id = $2;
+
+-- name: LockRideRequest :one
+SELECT id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at
+FROM ride_requests
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListRideRequestsByOffer :many
+SELECT r.id, r.conference_id, r.passenger_id, r.offer_id, r.status, r.pickup_city, r.pickup_latitude, r.pickup_longitude, r.notes, r.created_at, r.updated_at,
+       u.email, u.name, u.nickname, u.city
+FROM ride_requests r
+JOIN users u ON u.id = r.passenger_id
+JOIN conference_registrations cr ON cr.conference_id = r.conference_id AND cr.user_id = r.passenger_id AND cr.status != 'cancelled'
+WHERE r.offer_id = $1 AND r.status IN ('pending', 'accepted')
+ORDER BY r.created_at ASC;
+
+-- name: UpdateRideRequestStatus :one
+UPDATE ride_requests SET status = $2, offer_id = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING id, conference_id, passenger_id, offer_id, status, pickup_city, pickup_latitude, pickup_longitude, notes, created_at, updated_at;
+
+-- name: ReopenRideRequestsByOffer :many
+UPDATE ride_requests SET status = 'open', offer_id = NULL, updated_at = NOW()
+WHERE offer_id = $1 AND status IN ('pending', 'accepted')
+RETURNING passenger_id;
+
+-- name: RecordRideDecline :exec
+INSERT INTO ride_declines (offer_id, passenger_id)
+VALUES ($1, $2)
+ON CONFLICT (offer_id, passenger_id) DO NOTHING;
+
+-- name: IsRideDeclined :one
+SELECT EXISTS (
+    SELECT 1 FROM ride_declines WHERE offer_id = $1 AND passenger_id = $2
+);
+
+-- name: ListDeclinedRideOffers :many
+SELECT d.offer_id
+FROM ride_declines d
+JOIN ride_offers o ON o.id = d.offer_id
+WHERE o.conference_id = $1 AND d.passenger_id = $2;
+
+-- name: SetRegistrationRideFlags :exec
+UPDATE conference_registrations SET
+    needs_ride = COALESCE(sqlc.narg('needs_ride'), needs_ride),
+    has_car = COALESCE(sqlc.narg('has_car'), has_car)
+WHERE user_id = sqlc.arg('user_id') AND conference_id = sqlc.arg('conference_id');
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: Requests tokens are refilled every Per, up to Burst
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+// LockoutPolicy locks a key out after Threshold consecutive failures.
+// Each further failure doubles the lockout, from Base up to Max.
+type LockoutPolicy struct {
+	Threshold int           `yaml:"threshold"`
+	Base      time.Duration `yaml:"base"`
+	Max       time.Duration `yaml:"max"`
+	// Window is how long failures are remembered after the last one
+	Window time.Duration `yaml:"window"`
+}
+
+// lockoutFor returns the lockout duration after the given number of consecutive failures
+func (p LockoutPolicy) lockoutFor(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	exp := failures - p.Threshold
+	if exp > 30 {
+		return p.Max
+	}
+	d := p.Base << exp
+	if d > p.Max || d <= 0 {
+		return p.Max
+	}
+	return d
+}
+
+// RateLimitStore holds rate limiting and lockout state.
+// The in-memory store is used by default; a shared store (e.g. Redis) can
+// implement this interface to apply the same limits across instances.
+type RateLimitStore interface {
+	// Take consumes a token from the bucket of key. It returns 0 if the request
+	// is allowed, otherwise how long to wait before retrying.
+	Take(ctx context.Context, key string, limit RateLimit) (time.Duration, error)
+	// LockedFor returns how long key is still locked out (0 if it is not)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Fail records a failed attempt for key and returns the resulting lockout
+	Fail(ctx context.Context, key string, policy LockoutPolicy) (time.Duration, error)
+	// Reset forgets the failed attempts of key
+	Reset(ctx context.Context, key string) error
+}
+
+// MemoryRateLimitStore is a RateLimitStore local to the process
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	buckets   map[string]*bucket
+	failures  map[string]*failures
+	lastSweep time.Time
+}
+
+// bucket is the state of a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration // time to refill completely, after which the bucket can be dropped
+}
+
+// failures is the lockout state of a key
+type failures struct {
+	count       int
+	lockedUntil time.Time
+	expires     time.Time
+}
+
+// memorySweepInterval is how often expired entries are removed from the memory store
+const memorySweepInterval = time.Minute
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+		failures: make(map[string]*failures),
+	}
+}
+
+// Take implements RateLimitStore
+func (m *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	perToken := limit.Per / time.Duration(limit.Requests)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		m.buckets[key] = b
+	}
+	b.idle = perToken * time.Duration(limit.Burst)
+
+	elapsed := now.Sub(b.last)
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+float64(elapsed)/float64(perToken))
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, nil
+	}
+	return time.Duration((1 - b.tokens) * float64(perToken)), nil
+}
+
+// LockedFor implements RateLimitStore
+func (m *MemoryRateLimitStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if f, ok := m.failures[key]; ok && f.lockedUntil.After(now) {
+		return f.lockedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// Fail implements RateLimitStore
+func (m *MemoryRateLimitStore) Fail(_ context.Context, key string, policy LockoutPolicy) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	f, ok := m.failures[key]
+	if !ok || !f.expires.After(now) {
+		f = &failures{}
+		m.failures[key] = f
+	}
+	f.count++
+
+	lockout := policy.lockoutFor(f.count)
+	f.lockedUntil = now.Add(lockout)
+	f.expires = f.lockedUntil.Add(policy.Window)
+	return lockout, nil
+}
+
+// Reset implements RateLimitStore
+func (m *MemoryRateLimitStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.failures, key)
+	return nil
+}
+
+// sweep drops full buckets and expired failures; the caller holds m.mu
+func (m *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if now.Sub(b.last) >= b.idle {
+			delete(m.buckets, key)
+		}
+	}
+	for key, f := range m.failures {
+		if !f.expires.After(now) {
+			delete(m.failures, key)
+		}
+	}
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// trusted when the server runs behind a reverse proxy that sets it.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tooManyRequests writes a 429 response with the Retry-After header in whole seconds
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, http.StatusTooManyRequests, CodeRateLimited, "Too many requests")
+}
+
+// takeAll consumes a token from each bucket and returns the longest wait.
+// Store errors are logged and the request is allowed, so an unavailable
+// shared store does not take the API down.
+func (s *Server) takeAll(ctx context.Context, limit RateLimit, keys ...string) time.Duration {
+	var wait time.Duration
+	for _, key := range keys {
+		retryAfter, err := s.limits.Take(ctx, key, limit)
+		if err != nil {
+			slog.ErrorContext(ctx, "Rate limit store error", "error", err)
+			continue
+		}
+		wait = max(wait, retryAfter)
+	}
+	return wait
+}
+
+// unlimitedPaths are probed by infrastructure and never rate limited
+var unlimitedPaths = map[string]bool{
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// rateLimitMiddleware applies the general API token bucket, keyed by client IP.
+// Health checks and metrics scrapes are not limited.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.cfg.RateLimit.Enabled || unlimitedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := clientIP(r, s.cfg.RateLimit.TrustProxy)
+		if wait := s.takeAll(r.Context(), s.cfg.RateLimit.API, "api:ip:"+ip); wait > 0 {
+			tooManyRequests(w, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitAuthAttempt applies the stricter login/registration buckets, keyed by
+// client IP and by email. It writes a 429 response and returns false when exceeded.
+func (s *Server) limitAuthAttempt(w http.ResponseWriter, r *http.Request, email string) bool {
+	if !s.cfg.RateLimit.Enabled {
+		return true
+	}
+
+	ip := clientIP(r, s.cfg.RateLimit.TrustProxy)
+	wait := max(
+		s.takeAll(r.Context(), s.cfg.RateLimit.AuthIP, "auth:ip:"+ip),
+		s.takeAll(r.Context(), s.cfg.RateLimit.AuthEmail, "auth:email:"+normalizeEmail(email)),
+	)
+	if wait > 0 {
+		tooManyRequests(w, wait)
+		return false
+	}
+	return true
+}
+
+// limitAuthIP applies the per-IP authentication limit to requests that carry
+// no email, like password resets. It writes a 429 response and returns false
+// when the limit is exceeded.
+func (s *Server) limitAuthIP(w http.ResponseWriter, r *http.Request) bool {
+	if !s.cfg.RateLimit.Enabled {
+		return true
+	}
+
+	ip := clientIP(r, s.cfg.RateLimit.TrustProxy)
+	if wait := s.takeAll(r.Context(), s.cfg.RateLimit.AuthIP, "auth:ip:"+ip); wait > 0 {
+		tooManyRequests(w, wait)
+		return false
+	}
+	return true
+}
+
+// loginLockoutKey identifies the failed logins of a client for an email.
+// Keying by both prevents attackers from locking legitimate users out.
+func (s *Server) loginLockoutKey(r *http.Request, email string) string {
+	return "login:" + clientIP(r, s.cfg.RateLimit.TrustProxy) + "|" + normalizeEmail(email)
+}
+
+// checkLoginLockout writes a 429 response and returns false while the login is locked out
+func (s *Server) checkLoginLockout(w http.ResponseWriter, r *http.Request, email string) bool {
+	if !s.cfg.RateLimit.Enabled {
+		return true
+	}
+
+	lockedFor, err := s.limits.LockedFor(r.Context(), s.loginLockoutKey(r, email))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Rate limit store error", "error", err)
+		return true
+	}
+	if lockedFor > 0 {
+		tooManyRequests(w, lockedFor)
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure counts a failed login towards the lockout
+func (s *Server) recordLoginFailure(r *http.Request, email string) {
+	if !s.cfg.RateLimit.Enabled {
+		return
+	}
+
+	lockout, err := s.limits.Fail(r.Context(), s.loginLockoutKey(r, email), s.cfg.RateLimit.Lockout)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Rate limit store error", "error", err)
+		return
+	}
+	if lockout > 0 {
+		slog.WarnContext(r.Context(), "Login locked out after repeated failures",
+			"ip", clientIP(r, s.cfg.RateLimit.TrustProxy), "lockout", lockout)
+	}
+}
+
+// resetLoginFailures clears the lockout state after a successful login
+func (s *Server) resetLoginFailures(r *http.Request, email string) {
+	if !s.cfg.RateLimit.Enabled {
+		return
+	}
+
+	if err := s.limits.Reset(r.Context(), s.loginLockoutKey(r, email)); err != nil {
+		slog.ErrorContext(r.Context(), "Rate limit store error", "error", err)
+	}
+}
+
+// normalizeEmail lowercases and trims an email so limits apply regardless of spelling
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
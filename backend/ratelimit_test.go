@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock restituisce un'ora controllata dal test
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestStore() (*MemoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 5, 10, 8, 0, 0, 0, time.UTC)}
+	store := NewMemoryRateLimitStore()
+	store.now = clock.now
+	return store, clock
+}
+
+// Test token bucket: burst iniziale, attesa calcolata e ricarica nel tempo
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+	limit := RateLimit{Requests: 6, Per: time.Minute, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if wait, _ := store.Take(ctx, "k", limit); wait != 0 {
+			t.Fatalf("Request %d: expected to be allowed, got wait %s", i, wait)
+		}
+	}
+	wait, _ := store.Take(ctx, "k", limit)
+	if wait != 10*time.Second {
+		t.Fatalf("Expected wait of 10s with an empty bucket, got %s", wait)
+	}
+	if other, _ := store.Take(ctx, "other", limit); other != 0 {
+		t.Error("Expected buckets to be independent per key")
+	}
+
+	clock.t = clock.t.Add(10 * time.Second)
+	if wait, _ := store.Take(ctx, "k", limit); wait != 0 {
+		t.Errorf("Expected a refilled token after 10s, got wait %s", wait)
+	}
+	if wait, _ := store.Take(ctx, "k", limit); wait == 0 {
+		t.Error("Expected bucket to be empty again")
+	}
+
+	// Dopo la ricarica completa il bucket viene eliminato dallo sweep
+	clock.t = clock.t.Add(time.Hour)
+	store.Take(ctx, "fresh", limit)
+	if _, ok := store.buckets["k"]; ok {
+		t.Error("Expected idle bucket to be swept")
+	}
+}
+
+// Test blocco esponenziale dopo tentativi di login falliti
+func TestMemoryRateLimitStoreLockout(t *testing.T) {
+	ctx := context.Background()
+	store, clock := newTestStore()
+	policy := LockoutPolicy{Threshold: 3, Base: time.Minute, Max: 5 * time.Minute, Window: time.Hour}
+
+	expected := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+	for i, want := range expected {
+		got, _ := store.Fail(ctx, "k", policy)
+		if got != want {
+			t.Errorf("Failure %d: expected lockout %s, got %s", i+1, want, got)
+		}
+	}
+	if locked, _ := store.LockedFor(ctx, "k"); locked != 5*time.Minute {
+		t.Errorf("Expected key locked for 5m, got %s", locked)
+	}
+
+	clock.t = clock.t.Add(5 * time.Minute)
+	if locked, _ := store.LockedFor(ctx, "k"); locked != 0 {
+		t.Errorf("Expected lockout to be over, got %s", locked)
+	}
+	// I fallimenti restano memorizzati: il tentativo successivo blocca subito
+	if got, _ := store.Fail(ctx, "k", policy); got != 5*time.Minute {
+		t.Errorf("Expected lockout to continue after another failure, got %s", got)
+	}
+
+	store.Reset(ctx, "k")
+	if got, _ := store.Fail(ctx, "k", policy); got != 0 {
+		t.Errorf("Expected no lockout after reset, got %s", got)
+	}
+
+	// Fallimenti più vecchi della finestra vengono dimenticati
+	store.Fail(ctx, "k", policy)
+	clock.t = clock.t.Add(2 * time.Hour)
+	if got, _ := store.Fail(ctx, "k", policy); got != 0 {
+		t.Errorf("Expected failures to expire after the window, got %s", got)
+	}
+}
+
+// Test estrazione dell'IP del client, con e senza proxy fidato
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:51234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	if got := clientIP(req, false); got != "192.0.2.1" {
+		t.Errorf("Expected RemoteAddr host without trusted proxy, got %q", got)
+	}
+	if got := clientIP(req, true); got != "203.0.113.7" {
+		t.Errorf("Expected first X-Forwarded-For entry behind proxy, got %q", got)
+	}
+}
+
+// Test risposta 429 con Retry-After dal middleware generale
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.API = RateLimit{Requests: 1, Per: time.Minute, Burst: 2}
+	s := NewServer(nil, cfg)
+	handler := s.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = addr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := do("/api/conferences", "192.0.2.1:1000"); rr.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i, rr.Code)
+		}
+	}
+	rr := do("/api/conferences", "192.0.2.1:1001")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+	if rr := do("/api/conferences", "192.0.2.2:1000"); rr.Code != http.StatusOK {
+		t.Errorf("Expected other clients not to be limited, got %d", rr.Code)
+	}
+	if rr := do("/health", "192.0.2.1:1000"); rr.Code != http.StatusOK {
+		t.Errorf("Expected health check not to be limited, got %d", rr.Code)
+	}
+}
+
+// Test blocco del login per coppia IP/email
+func TestLoginLockout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Lockout = LockoutPolicy{Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	s := NewServer(nil, cfg)
+
+	req := httptest.NewRequest("POST", "/api/login", nil)
+	req.RemoteAddr = "192.0.2.1:1000"
+	other := httptest.NewRequest("POST", "/api/login", nil)
+	other.RemoteAddr = "192.0.2.2:1000"
+
+	s.recordLoginFailure(req, "mario@example.com")
+	if !s.checkLoginLockout(httptest.NewRecorder(), req, "mario@example.com") {
+		t.Fatal("Expected no lockout below threshold")
+	}
+	s.recordLoginFailure(req, " Mario@Example.com")
+
+	rr := httptest.NewRecorder()
+	if s.checkLoginLockout(rr, req, "mario@example.com") {
+		t.Fatal("Expected lockout after reaching threshold")
+	}
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected 429 with Retry-After 60, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if !s.checkLoginLockout(httptest.NewRecorder(), other, "mario@example.com") {
+		t.Error("Expected other clients to still be able to log in")
+	}
+
+	s.resetLoginFailures(req, "mario@example.com")
+	if !s.checkLoginLockout(httptest.NewRecorder(), req, "mario@example.com") {
+		t.Error("Expected lockout to be cleared after a successful login")
+	}
+}
+
+// Test limite per IP delle richieste di autenticazione senza email, come il reset password
+func TestLimitAuthIP(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.AuthIP = RateLimit{Requests: 1, Per: time.Minute, Burst: 1}
+	s := NewServer(nil, cfg)
+
+	req := httptest.NewRequest("POST", "/api/v1/password/reset", nil)
+	req.RemoteAddr = "192.0.2.1:1000"
+
+	if !s.limitAuthIP(httptest.NewRecorder(), req) {
+		t.Fatal("Expected first attempt to be allowed")
+	}
+	rr := httptest.NewRecorder()
+	if s.limitAuthIP(rr, req) {
+		t.Fatal("Expected second attempt to be limited")
+	}
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected 429 with Retry-After 60, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	// Il bucket è lo stesso dei login: un IP non può alternare gli endpoint per aggirarlo
+	if s.limitAuthAttempt(httptest.NewRecorder(), req, "mario@example.com") {
+		t.Error("Expected login attempts from the same IP to share the limit")
+	}
+}
@@ -1,21 +1,249 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-// corsMiddleware handles Cross-Origin Resource Sharing (CORS)
-func corsMiddleware(next http.Handler) http.Handler {
+// contentSecurityPolicy allows nothing: the API only serves JSON and must never be framed
+const contentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+
+// corsAllowedHeaders are the request headers the frontend may send cross-origin
+const corsAllowedHeaders = "Content-Type, Authorization, If-Match, X-Request-ID"
+
+// corsExposedHeaders are the response headers readable by cross-origin scripts
+const corsExposedHeaders = "ETag, Retry-After, X-Request-ID, Deprecation, Sunset, Link"
+
+// corsMethods are the methods probed against the mux to build Access-Control-Allow-Methods
+var corsMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// corsPolicy decides which origins may call the API
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   []originWildcard
+	credentials bool
+	maxAge      string
+}
+
+// originWildcard matches origins like https://*.example.com (any subdomain, not the apex)
+type originWildcard struct {
+	prefix string // scheme://
+	suffix string // .example.com[:port]
+}
+
+// newCORSPolicy builds the policy from a validated configuration
+func newCORSPolicy(cfg CORSConfig) corsPolicy {
+	p := corsPolicy{
+		origins:     make(map[string]bool),
+		credentials: cfg.AllowCredentials,
+		maxAge:      strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			p.wildcards = append(p.wildcards, originWildcard{prefix: scheme + "://", suffix: host})
+		default:
+			p.origins[origin] = true
+		}
+	}
+	return p
+}
+
+// allowed reports whether the given Origin header value is allowed
+func (p corsPolicy) allowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+			continue
+		}
+		sub := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+		if sub != "" && !strings.ContainsAny(sub, "/:@") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOriginValue returns the Access-Control-Allow-Origin value for an allowed origin.
+// "*" is only used when any origin is allowed and credentials are not.
+func (p corsPolicy) allowOriginValue(origin string) string {
+	if p.anyOrigin && !p.credentials {
+		return "*"
+	}
+	return origin
+}
+
+// routeMethods returns the methods registered on mux for the request path
+func routeMethods(mux *http.ServeMux, r *http.Request) []string {
+	var methods []string
+	for _, method := range corsMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// joinMethods formats methods, plus OPTIONS, for the Allow header
+func joinMethods(methods []string) string {
+	return strings.Join(append(methods[:len(methods):len(methods)], http.MethodOptions), ", ")
+}
+
+// corsMiddleware handles Cross-Origin Resource Sharing (CORS).
+// Only origins allowed by cfg receive CORS headers. OPTIONS requests are answered
+// with the methods registered on mux for the path, or 404 for unknown routes;
+// other requests are passed to next. The headers are set before next runs, so
+// error responses of inner middleware (e.g. 429, 413) are readable by browsers too.
+func corsMiddleware(cfg CORSConfig, mux *http.ServeMux, next http.Handler) http.Handler {
+	policy := newCORSPolicy(cfg)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := r.Header.Get("Origin")
+		allowed := policy.allowed(origin)
+
+		// The response depends on Origin whenever it is not a plain "*"
+		w.Header().Add("Vary", "Origin")
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", policy.allowOriginValue(origin))
+			if policy.credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodOptions {
+			if allowed {
+				w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			}
+			next.ServeHTTP(w, r)
 			return
 		}
 
+		methods := routeMethods(mux, r)
+		if len(methods) == 0 {
+			writeError(w, http.StatusNotFound, CodeNotFound, "Not found")
+			return
+		}
+		allow := joinMethods(methods)
+		w.Header().Set("Allow", allow)
+
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if requestMethod == "" || origin == "" {
+			// Plain OPTIONS request, not a CORS preflight
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !allowed {
+			writeError(w, http.StatusForbidden, CodeOriginNotAllowed, "Origin not allowed")
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", allow)
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+		w.Header().Set("Access-Control-Max-Age", policy.maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// securityHeadersMiddleware sets the security headers common to every response
+func securityHeadersMiddleware(cfg HTTPConfig, next http.Handler) http.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", int(cfg.HSTSMaxAge.Seconds()))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		// Browsers ignore HSTS over plain HTTP, so it is safe to always send it
+		if hsts != "" {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		h.Set("Content-Security-Policy", contentSecurityPolicy)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyLimitMiddleware caps the request body with http.MaxBytesReader.
+// The limit is looked up by the mux pattern matching the request, falling back to cfg.MaxBodyBytes.
+func bodyLimitMiddleware(cfg HTTPConfig, mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := cfg.MaxBodyBytes
+		if len(cfg.RouteBodyLimits) > 0 {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				if routeLimit, ok := cfg.RouteBodyLimits[pattern]; ok {
+					limit = routeLimit
+				}
+			}
+		}
+
+		if r.ContentLength > limit {
+			writeError(w, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
 		next.ServeHTTP(w, r)
 	})
 }
+
+// errBodyTooLarge is returned by decodeJSON when the body exceeds the route limit
+var errBodyTooLarge = errors.New("request body too large")
+
+// decodeJSON decodes a single JSON object from the request body into dst.
+// Unknown fields and trailing data are rejected.
+func decodeJSON(r *http.Request, dst any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("request body is empty")
+	case err == nil:
+		if _, err = decoder.Token(); errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err == nil {
+			err = errors.New("unexpected data after JSON object")
+		}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errBodyTooLarge
+	}
+	return err
+}
+
+// writeDecodeError writes the response for a decodeJSON error
+func writeDecodeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body too large")
+		return
+	}
+	writeError(w, http.StatusBadRequest, CodeInvalidBody, "Invalid request body: "+err.Error())
+}
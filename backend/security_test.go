@@ -0,0 +1,300 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test riconoscimento delle origini consentite, incluse le wildcard sui sottodomini
+func TestCORSPolicyAllowed(t *testing.T) {
+	policy := newCORSPolicy(CORSConfig{AllowedOrigins: []string{
+		"https://conferenze.tech",
+		"https://*.conferenze.tech",
+		"http://localhost:5173",
+	}})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://conferenze.tech", true},
+		{"https://CONFERENZE.tech", true},
+		{"https://app.conferenze.tech", true},
+		{"https://a.b.conferenze.tech", true},
+		{"http://app.conferenze.tech", false},
+		{"https://evilconferenze.tech", false},
+		{"https://conferenze.tech.evil.com", false},
+		{"https://app.conferenze.tech:8443", false},
+		{"http://localhost:5173", true},
+		{"http://localhost:3000", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.allowed(tt.origin); got != tt.allowed {
+			t.Errorf("allowed(%q) = %v, expected %v", tt.origin, got, tt.allowed)
+		}
+	}
+}
+
+// Test header CORS su richieste semplici, preflight e OPTIONS su route sconosciute
+func TestCORSMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("GET /api/conferences/{conference_id}", ok)
+	mux.HandleFunc("PUT /api/conferences/{conference_id}", ok)
+	mux.HandleFunc("DELETE /api/conferences/{conference_id}", ok)
+
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"https://app.conferenze.tech"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	handler := corsMiddleware(cfg, mux, routeHandler(mux))
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		origin        string
+		requestMethod string
+		status        int
+		allowOrigin   string
+		allowMethods  string
+		maxAge        string
+	}{
+		{
+			name:        "Simple request from allowed origin",
+			method:      "GET",
+			path:        "/api/conferences/1",
+			origin:      "https://app.conferenze.tech",
+			status:      http.StatusOK,
+			allowOrigin: "https://app.conferenze.tech",
+		},
+		{
+			name:   "Simple request from unknown origin",
+			method: "GET",
+			path:   "/api/conferences/1",
+			origin: "https://evil.example",
+			status: http.StatusOK,
+		},
+		{
+			name:          "Preflight from allowed origin",
+			method:        "OPTIONS",
+			path:          "/api/conferences/1",
+			origin:        "https://app.conferenze.tech",
+			requestMethod: "PUT",
+			status:        http.StatusNoContent,
+			allowOrigin:   "https://app.conferenze.tech",
+			allowMethods:  "GET, HEAD, PUT, DELETE, OPTIONS",
+			maxAge:        "600",
+		},
+		{
+			name:          "Preflight from unknown origin",
+			method:        "OPTIONS",
+			path:          "/api/conferences/1",
+			origin:        "https://evil.example",
+			requestMethod: "PUT",
+			status:        http.StatusForbidden,
+		},
+		{
+			name:          "Preflight on unknown route",
+			method:        "OPTIONS",
+			path:          "/api/unknown",
+			origin:        "https://app.conferenze.tech",
+			requestMethod: "GET",
+			status:        http.StatusNotFound,
+			allowOrigin:   "https://app.conferenze.tech",
+		},
+		{
+			name:   "Plain OPTIONS request",
+			method: "OPTIONS",
+			path:   "/api/conferences/1",
+			status: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.allowOrigin, got)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Methods"); got != tt.allowMethods {
+				t.Errorf("Expected Access-Control-Allow-Methods %q, got %q", tt.allowMethods, got)
+			}
+			if got := rr.Header().Get("Access-Control-Max-Age"); got != tt.maxAge {
+				t.Errorf("Expected Access-Control-Max-Age %q, got %q", tt.maxAge, got)
+			}
+			if rr.Header().Get("Vary") != "Origin" {
+				t.Errorf("Expected Vary: Origin, got %q", rr.Header().Values("Vary"))
+			}
+			wantCredentials := ""
+			if tt.allowOrigin != "" {
+				wantCredentials = "true"
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Errorf("Expected Access-Control-Allow-Credentials %q, got %q", wantCredentials, got)
+			}
+		})
+	}
+}
+
+// Test "*" senza credenziali: l'origine non viene riflessa
+func TestCORSMiddlewareAnyOrigin(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/conferences", func(w http.ResponseWriter, r *http.Request) {})
+	cfg := DefaultConfig().CORS
+	cfg.AllowedOrigins = []string{"*"}
+	handler := corsMiddleware(cfg, mux, routeHandler(mux))
+
+	req := httptest.NewRequest("GET", "/api/conferences", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected Access-Control-Allow-Origin *, got %q", got)
+	}
+}
+
+// Test header di sicurezza comuni a tutte le risposte
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	handler := securityHeadersMiddleware(DefaultConfig().HTTP, http.NotFoundHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/unknown", nil))
+
+	expected := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   contentSecurityPolicy,
+	}
+	for header, value := range expected {
+		if got := rr.Header().Get(header); got != value {
+			t.Errorf("Expected %s %q, got %q", header, value, got)
+		}
+	}
+
+	noHSTS := DefaultConfig().HTTP
+	noHSTS.HSTSMaxAge = 0
+	rr = httptest.NewRecorder()
+	securityHeadersMiddleware(noHSTS, http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if got := rr.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("Expected no HSTS header when disabled, got %q", got)
+	}
+}
+
+// Test limiti sul body per route e decodifica JSON rigorosa
+func TestBodyLimitAndDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	mux := http.NewServeMux()
+	decode := func(w http.ResponseWriter, r *http.Request) {
+		var p payload
+		if err := decodeJSON(r, &p); err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	mux.HandleFunc("POST /small", decode)
+	mux.HandleFunc("POST /large", decode)
+
+	cfg := HTTPConfig{MaxBodyBytes: 1024, RouteBodyLimits: map[string]int64{"POST /small": 20}}
+	handler := bodyLimitMiddleware(cfg, mux, mux)
+
+	long := `{"name":"` + strings.Repeat("x", 100) + `"}`
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"Valid body", "/large", `{"name":"Go"}`, false, http.StatusOK},
+		{"Body above route limit", "/small", long, false, http.StatusRequestEntityTooLarge},
+		{"Chunked body above route limit", "/small", long, true, http.StatusRequestEntityTooLarge},
+		{"Same body below default limit", "/large", long, false, http.StatusOK},
+		{"Unknown field", "/large", `{"name":"Go","admin":true}`, false, http.StatusBadRequest},
+		{"Trailing data", "/large", `{"name":"Go"}{"name":"Rust"}`, false, http.StatusBadRequest},
+		{"Empty body", "/large", ``, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d (%s)", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+// Test header CORS anche sulle risposte dei limiti (429 e 413), così il browser può leggerle
+func TestCORSOnLimitResponses(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.API = RateLimit{Requests: 1, Per: time.Minute, Burst: 1}
+	handler := NewServer(nil, cfg).Handler()
+	origin := cfg.CORS.AllowedOrigins[0]
+
+	do := func(method, path string, contentLength int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		req.ContentLength = contentLength
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tooLarge := do("POST", "/api/v1/login", cfg.HTTP.MaxBodyBytes+1)
+	if tooLarge.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", tooLarge.Code)
+	}
+	limited := do("GET", "/api/v1/unknown", 0)
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", limited.Code)
+	}
+
+	for name, rr := range map[string]*httptest.ResponseRecorder{"413": tooLarge, "429": limited} {
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("%s: expected Access-Control-Allow-Origin %q, got %q", name, origin, got)
+		}
+		if !strings.Contains(rr.Header().Get("Access-Control-Expose-Headers"), "Retry-After") {
+			t.Errorf("%s: expected Retry-After to be exposed, got %q", name, rr.Header().Get("Access-Control-Expose-Headers"))
+		}
+	}
+
+	// I preflight ricevono risposta anche quando il client ha esaurito il limite
+	req := httptest.NewRequest("OPTIONS", "/api/v1/login", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected preflight 204 while rate limited, got %d", rr.Code)
+	}
+}
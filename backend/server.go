@@ -1,63 +1,269 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/marco-introini/conferenze.tech/backend/db"
 )
 
 // Server represents the HTTP server with database access
 type Server struct {
-	db *db.Queries
+	db      *db.DB
+	cfg     Config
+	tokens  TokenPolicy
+	limits  RateLimitStore
+	metrics *Metrics
+	checks  []ReadinessCheck
+	mailer  Mailer
+
+	// accountKey signs the tokens emailed to account owners
+	accountKey []byte
+
+	// background tracks work started by handlers that outlives the request
+	background sync.WaitGroup
 }
 
-// NewServer creates a new Server instance
-func NewServer(database *db.Queries) *Server {
-	return &Server{db: database}
+// NewServer creates a new Server instance with the given configuration.
+// Rate limits are kept in memory; use SetRateLimitStore to share them across instances.
+// Emails are written to standard error; use SetMailer to send them.
+func NewServer(database *db.DB, cfg Config) *Server {
+	metrics := newMetrics()
+	metrics.registry.MustRegister(newConferenceCollector(database, cfg.RequestTimeout))
+	return &Server{
+		db:         database,
+		cfg:        cfg,
+		tokens:     cfg.Tokens.TokenPolicy,
+		limits:     NewMemoryRateLimitStore(),
+		metrics:    metrics,
+		mailer:     NewLogMailer(os.Stderr, cfg.Mail.From),
+		accountKey: accountKey(cfg.Accounts),
+	}
 }
 
-// Run starts the HTTP server with all routes configured
-func (s *Server) Run(port string) error {
-	mux := http.NewServeMux()
+// SetRateLimitStore replaces the rate limit store. It must be called before Run.
+func (s *Server) SetRateLimitStore(store RateLimitStore) {
+	s.limits = store
+}
+
+// Run starts the HTTP server and the background jobs, and blocks until ctx is
+// cancelled. In-flight requests are then drained within the configured shutdown timeout.
+// When Run returns no handler or background job is using the database anymore.
+func (s *Server) Run(ctx context.Context, port string) error {
+	addr := fmt.Sprintf(":%s", port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	var jobs sync.WaitGroup
+	defer func() {
+		cancelJobs()
+		jobs.Wait()
+	}()
+
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		s.runTokenJanitor(jobsCtx, s.cfg.Tokens.CleanupInterval)
+	}()
+
+	slog.Info("Server starting", "addr", addr)
+	err = serve(ctx, s.newHTTPServer(s.Handler()), ln, s.cfg.HTTP.ShutdownTimeout)
+	s.background.Wait()
+	return err
+}
+
+// goBackground runs fn after the request that started it has completed,
+// with a context that is not cancelled with the request. Run waits for it
+// before returning.
+func (s *Server) goBackground(r *http.Request, fn func(ctx context.Context)) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), s.cfg.RequestTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// newHTTPServer builds an http.Server with the configured limits
+func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: s.cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       s.cfg.HTTP.ReadTimeout,
+		WriteTimeout:      s.cfg.HTTP.WriteTimeout,
+		IdleTimeout:       s.cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes:    s.cfg.HTTP.MaxHeaderBytes,
+	}
+}
+
+// serve accepts connections on ln until ctx is cancelled, then shuts the server
+// down gracefully. Connections still active after shutdownTimeout are closed.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, shutdownTimeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	// Public routes (no authentication required)
-	mux.HandleFunc("POST /api/register", s.Register)
-	mux.HandleFunc("POST /api/login", s.Login)
-	mux.HandleFunc("GET /api/conferences", s.ListConferences)
-	mux.HandleFunc("GET /api/conferences/{conference_id}", s.GetConference)
-
-	// Protected routes (authentication required)
-	s.protectedRoute(mux, "POST /api/conferences", s.CreateConference)
-	s.protectedRoute(mux, "DELETE /api/conferences/{conference_id}", s.DeleteConference)
-	s.protectedRoute(mux, "POST /api/conferences/{conference_id}/register", s.RegisterToConference)
-	s.protectedRoute(mux, "GET /api/users/registrations", s.GetUserRegistrations)
-	s.protectedRoute(mux, "DELETE /api/users/registrations/{conference_id}", s.UnregisterFromConference)
-	s.protectedRoute(mux, "GET /api/users/{user_id}", s.GetMe)
-	s.protectedRoute(mux, "GET /api/me", s.GetMeFromToken)
-	s.protectedRoute(mux, "PUT /api/me", s.UpdateMe)
-	s.protectedRoute(mux, "GET /api/tokens", s.GetTokens)
-	s.protectedRoute(mux, "POST /api/tokens/revoke", s.RevokeToken)
-
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		if _, err := io.WriteString(w, "OK"); err != nil {
-			log.Printf("Failed to write health check response: %v", err)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("Server stopped")
+	return nil
+}
+
+// Access levels of a route
+type access int
+
+const (
+	accessPublic        access = iota // no authentication required
+	accessAuthenticated               // a valid bearer token is required
+	accessVerified                    // the user must also have verified the email address
+	accessAdmin                       // the user must be a platform administrator
+)
+
+// route is an entry of the route table. The same table registers the handlers
+// and builds the OpenAPI document (see openapi.go), so the two cannot drift apart.
+type route struct {
+	pattern string
+	access  access
+	handler http.HandlerFunc
+}
+
+// v1Routes returns the route table of version 1 of the API, with patterns
+// relative to the version prefix
+func (s *Server) v1Routes() []route {
+	return []route{
+		// Public routes (no authentication required)
+		{"POST /register", accessPublic, s.Register},
+		{"POST /login", accessPublic, s.Login},
+		{"POST /verify-email", accessPublic, s.VerifyEmail},
+		{"POST /password/forgot", accessPublic, s.ForgotPassword},
+		{"POST /password/reset", accessPublic, s.ResetPassword},
+		{"GET /conferences", accessPublic, s.ListConferences},
+		{"GET /conferences/nearby", accessPublic, s.NearbyConferences},
+		{"GET /conferences/{conference_id}", accessPublic, s.GetConference},
+		{"GET /conferences/{conference_id}/stats", accessPublic, s.GetConferenceStats},
+
+		// Protected routes (authentication required)
+		{"POST /conferences", accessVerified, s.CreateConference},
+		{"PUT /conferences/{conference_id}", accessAuthenticated, s.UpdateConference},
+		{"PATCH /conferences/{conference_id}", accessAuthenticated, s.UpdateConference},
+		{"DELETE /conferences/{conference_id}", accessAuthenticated, s.DeleteConference},
+		{"GET /conferences/{conference_id}/registrations", accessAuthenticated, s.ListConferenceRegistrations},
+		{"PUT /conferences/{conference_id}/organizers/{user_id}", accessAuthenticated, s.AddOrganizer},
+		{"DELETE /conferences/{conference_id}/organizers/{user_id}", accessAuthenticated, s.RemoveOrganizer},
+		{"POST /conferences/{conference_id}/register", accessVerified, s.RegisterToConference},
+		{"GET /conferences/{conference_id}/rides", accessAuthenticated, s.GetConferenceRides},
+		{"POST /conferences/{conference_id}/rides/offers", accessAuthenticated, s.CreateRideOffer},
+		{"POST /conferences/{conference_id}/rides/requests", accessAuthenticated, s.CreateRideRequest},
+		{"GET /conferences/{conference_id}/rides/matches", accessAuthenticated, s.GetRideMatches},
+		{"DELETE /rides/offers/{offer_id}", accessAuthenticated, s.DeleteRideOffer},
+		{"GET /rides/offers/{offer_id}/requests", accessAuthenticated, s.ListRideOfferRequests},
+		{"POST /rides/offers/{offer_id}/join", accessAuthenticated, s.JoinRideOffer},
+		{"POST /rides/requests/{request_id}/accept", accessAuthenticated, s.AcceptRideRequest},
+		{"POST /rides/requests/{request_id}/decline", accessAuthenticated, s.DeclineRideRequest},
+		{"DELETE /rides/requests/{request_id}", accessAuthenticated, s.CancelRideRequest},
+		{"GET /users/registrations", accessAuthenticated, s.GetUserRegistrations},
+		{"DELETE /users/registrations/{conference_id}", accessAuthenticated, s.UnregisterFromConference},
+		{"GET /users/{user_id}", accessAuthenticated, s.GetMe},
+		{"GET /me", accessAuthenticated, s.GetMeFromToken},
+		{"PUT /me", accessAuthenticated, s.UpdateMe},
+		{"PUT /me/password", accessAuthenticated, s.ChangePassword},
+		{"POST /verify-email/resend", accessAuthenticated, s.ResendVerificationEmail},
+		{"GET /tokens", accessAuthenticated, s.GetTokens},
+		{"POST /tokens/revoke", accessAuthenticated, s.RevokeToken},
+		{"POST /tokens/revoke-others", accessAuthenticated, s.RevokeOtherTokens},
+		{"POST /logout", accessAuthenticated, s.Logout},
+
+		// Admin routes (platform administrators only)
+		{"PUT /admin/users/{user_id}/admin", accessAdmin, s.SetUserAdmin},
+	}
+}
+
+// routes returns the route table of the server: the routes of every API
+// version mounted under its prefix, followed by the unversioned routes
+func (s *Server) routes() []route {
+	var routes []route
+	for _, version := range s.apiVersions() {
+		for _, rt := range version.routes {
+			rt.pattern = prefixPattern(version.prefix, rt.pattern)
+			routes = append(routes, rt)
 		}
-	})
+	}
 
-	// Apply middleware chain
-	handler := loggingMiddleware(corsMiddleware(mux))
+	routes = append(routes,
+		// API documentation, covering every version
+		route{"GET /api/openapi.json", accessPublic, s.OpenAPISpec},
+		route{"GET /api/docs", accessPublic, s.APIDocs},
 
-	addr := fmt.Sprintf(":%s", port)
-	log.Printf("Server starting on %s", addr)
-	return http.ListenAndServe(addr, handler)
+		// Health checks: liveness of the process and readiness to take traffic.
+		// /health is kept as an alias of /livez for existing probes.
+		route{"GET /livez", accessPublic, s.Livez},
+		route{"GET /health", accessPublic, s.Livez},
+		route{"GET /readyz", accessPublic, s.Readyz},
+	)
+
+	// Prometheus metrics, scraped by the monitoring system
+	if s.cfg.Metrics.Enabled {
+		routes = append(routes, route{"GET /metrics", accessPublic, s.metrics.metricsHandler(s.cfg.Metrics.Token).ServeHTTP})
+	}
+	return routes
+}
+
+// Handler returns the HTTP handler with all routes and middleware configured
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range s.routes() {
+		mux.Handle(rt.pattern, s.withAccess(rt.access, rt.handler))
+	}
+	for _, alias := range s.legacyRoutes() {
+		mux.Handle(alias.pattern, deprecationMiddleware(s.withAccess(alias.access, alias.handler)))
+	}
+
+	// Apply middleware chain. CORS comes first so that responses of the limiters
+	// carry the CORS headers, and preflights are answered before any limit applies.
+	handler := securityHeadersMiddleware(s.cfg.HTTP, corsMiddleware(s.cfg.CORS, mux,
+		s.rateLimitMiddleware(bodyLimitMiddleware(s.cfg.HTTP, mux, routeHandler(mux)))))
+	if s.cfg.Metrics.Enabled {
+		handler = s.metrics.metricsMiddleware(mux, handler)
+	}
+	return requestIDMiddleware(loggingMiddleware(handler))
 }
 
-// protectedRoute registers a route that requires authentication
-func (s *Server) protectedRoute(mux *http.ServeMux, pattern string, handler http.HandlerFunc) {
-	mux.Handle(pattern, s.authMiddleware(handler))
+// withAccess wraps handler with the middleware enforcing the access level
+func (s *Server) withAccess(level access, handler http.Handler) http.Handler {
+	switch level {
+	case accessAuthenticated:
+		return s.authMiddleware(handler)
+	case accessVerified:
+		return s.authMiddleware(s.verifiedMiddleware(handler))
+	case accessAdmin:
+		return s.authMiddleware(s.adminMiddleware(handler))
+	}
+	return handler
 }
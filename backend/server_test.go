@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test configurazione dei limiti di http.Server
+func TestNewHTTPServer(t *testing.T) {
+	cfg := DefaultConfig()
+	s := NewServer(nil, cfg)
+	srv := s.newHTTPServer(http.NotFoundHandler())
+
+	if srv.ReadHeaderTimeout != cfg.HTTP.ReadHeaderTimeout || srv.ReadTimeout != cfg.HTTP.ReadTimeout ||
+		srv.WriteTimeout != cfg.HTTP.WriteTimeout || srv.IdleTimeout != cfg.HTTP.IdleTimeout {
+		t.Errorf("Unexpected timeouts: %+v", srv)
+	}
+	if srv.MaxHeaderBytes != cfg.HTTP.MaxHeaderBytes {
+		t.Errorf("Expected MaxHeaderBytes %d, got %d", cfg.HTTP.MaxHeaderBytes, srv.MaxHeaderBytes)
+	}
+	if srv.WriteTimeout <= cfg.RequestTimeout {
+		t.Error("WriteTimeout must exceed RequestTimeout, otherwise slow handlers lose their response")
+	}
+}
+
+// Test shutdown: le richieste in corso vengono completate prima della chiusura
+func TestServeGracefulShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, srv, ln, 5*time.Second)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-responses
+	if res.err != nil || res.body != "done" {
+		t.Errorf("Expected in-flight request to complete, got %q, %v", res.body, res.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Error("Expected new connections to be refused after shutdown")
+	}
+}
+
+// Test shutdown oltre il timeout: le connessioni vengono chiuse e viene restituito un errore
+func TestServeShutdownTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, srv, ln, 50*time.Millisecond)
+	}()
+	go http.Get("http://" + ln.Addr().String())
+
+	<-started
+	cancel()
+
+	if err := <-served; err == nil {
+		t.Error("Expected error when in-flight requests exceed the shutdown timeout")
+	}
+}
+
+// Test lavoro in background: sopravvive alla richiesta e viene atteso allo shutdown
+func TestGoBackground(t *testing.T) {
+	s := NewServer(nil, DefaultConfig())
+
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/password/forgot", nil).WithContext(reqCtx)
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	s.goBackground(r, func(ctx context.Context) {
+		<-release
+		done <- ctx.Err()
+	})
+
+	// La risposta è già stata inviata: il contesto della richiesta viene cancellato
+	cancelReq()
+	close(release)
+	s.background.Wait()
+
+	if err := <-done; err != nil {
+		t.Errorf("Expected background context to outlive the request, got %v", err)
+	}
+}
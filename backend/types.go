@@ -3,8 +3,20 @@ package main
 // contextKey is a custom type for context keys to avoid collisions with other packages
 type contextKey string
 
-// UserIDKey is the context key for storing the authenticated user's ID
-const UserIDKey contextKey = "userID"
+// Context keys set by authMiddleware
+const (
+	// UserIDKey is the context key for storing the authenticated user's ID
+	UserIDKey contextKey = "userID"
+
+	// TokenIDKey is the context key for storing the ID of the token used for the request
+	TokenIDKey contextKey = "tokenID"
+)
+
+// RequestIDKey is the context key for the request ID set by requestIDMiddleware
+const RequestIDKey contextKey = "requestID"
+
+// requestLogKey is the context key for the *requestLog set by loggingMiddleware
+const requestLogKey contextKey = "requestLog"
 
 // LoginRequest represents the payload for user authentication.
 // Both Email and Password are required fields.
@@ -13,54 +25,128 @@ type LoginRequest struct {
 	Password string `json:"password"` // User's password (plain text, will be hashed server-side)
 }
 
+// VerifyEmailRequest represents the payload confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"` // Token of the link emailed at registration
+}
+
+// ForgotPasswordRequest represents the payload asking for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,max=255,email"` // Email address of the account
+}
+
+// ResetPasswordRequest represents the payload setting a new password with a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`             // Token of the emailed reset link
+	Password string `json:"password" validate:"required,password"` // New password
+}
+
+// ChangePasswordRequest represents the payload changing the password of the authenticated user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`      // Password in use, to confirm the change
+	NewPassword     string `json:"newPassword" validate:"required,password"` // New password
+}
+
 // RegisterRequest represents the payload for new user registration.
 // Email, Password, and Name are required fields.
 // Other fields are optional and can be provided to enrich the user profile.
 type RegisterRequest struct {
-	Email     string  `json:"email"`     // User's email address (required, must be unique)
-	Password  string  `json:"password"`  // User's password (required, min 8 characters recommended)
-	Name      string  `json:"name"`      // User's full name (required)
-	Nickname  *string `json:"nickname"`  // Optional display nickname for the user
-	City      *string `json:"city"`      // Optional city location of the user
-	AvatarURL *string `json:"avatarUrl"` // Optional URL to user's avatar image
-	Bio       *string `json:"bio"`       // Optional user biography or description
+	Email     string  `json:"email" validate:"required,max=255,email"` // User's email address (required, must be unique)
+	Password  string  `json:"password" validate:"required,password"`   // User's password (required, 8+ characters with letters and digits or symbols)
+	Name      string  `json:"name" validate:"required,max=255"`        // User's full name (required)
+	Nickname  *string `json:"nickname" validate:"notblank,max=100"`    // Optional display nickname for the user
+	City      *string `json:"city" validate:"notblank,max=100"`        // Optional city location of the user
+	AvatarURL *string `json:"avatarUrl" validate:"max=2048,url"`       // Optional URL to user's avatar image
+	Bio       *string `json:"bio" validate:"max=2000"`                 // Optional user biography or description
 }
 
 // UpdateMeRequest represents the payload for updating the authenticated user's profile.
 // All fields are optional.
 type UpdateMeRequest struct {
-	Name      *string `json:"name"`      // Optional new full name
-	Nickname  *string `json:"nickname"`  // Optional new display nickname
-	City      *string `json:"city"`      // Optional new city location
-	AvatarURL *string `json:"avatarUrl"` // Optional new avatar URL
-	Bio       *string `json:"bio"`       // Optional new biography
+	Name      *string `json:"name" validate:"notblank,max=255"`     // Optional new full name
+	Nickname  *string `json:"nickname" validate:"notblank,max=100"` // Optional new display nickname
+	City      *string `json:"city" validate:"notblank,max=100"`     // Optional new city location
+	AvatarURL *string `json:"avatarUrl" validate:"max=2048,url"`    // Optional new avatar URL
+	Bio       *string `json:"bio" validate:"max=2000"`              // Optional new biography
 }
 
 // CreateConferenceRequest represents the payload for creating a new conference.
 // Title, Date, and Location are required fields.
 type CreateConferenceRequest struct {
-	Title     string   `json:"title"`     // Conference title (required)
-	Date      string   `json:"date"`      // Conference date in RFC3339 format (required)
-	Location  string   `json:"location"`  // Conference location, city and country (required)
-	Website   *string  `json:"website"`   // Optional conference website URL
-	Latitude  *float64 `json:"latitude"`  // Optional GPS latitude coordinate
-	Longitude *float64 `json:"longitude"` // Optional GPS longitude coordinate
+	Title     string   `json:"title" validate:"required,max=255"`     // Conference title (required)
+	Date      string   `json:"date" validate:"required,rfc3339"`      // Conference date in RFC3339 format (required)
+	Location  string   `json:"location" validate:"required,max=255"`  // Conference location, city and country (required)
+	Website   *string  `json:"website" validate:"max=2048,url"`       // Optional conference website URL
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`    // Optional GPS latitude coordinate
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"` // Optional GPS longitude coordinate
+	Capacity  *int32   `json:"capacity" validate:"min=1"`             // Optional maximum number of confirmed registrations
+}
+
+// UpdateConferenceRequest represents the payload for a partial update of a conference.
+// All fields are optional: omitted fields keep their current value.
+type UpdateConferenceRequest struct {
+	Title     *string  `json:"title" validate:"notblank,max=255"`     // Optional new title
+	Date      *string  `json:"date" validate:"rfc3339"`               // Optional new date in RFC3339 format
+	Location  *string  `json:"location" validate:"notblank,max=255"`  // Optional new location
+	Website   *string  `json:"website" validate:"max=2048,url"`       // Optional new website URL
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`    // Optional new GPS latitude coordinate
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"` // Optional new GPS longitude coordinate
+	Capacity  *int32   `json:"capacity" validate:"min=1"`             // Optional new capacity; waitlisted users are promoted if seats free up
+
+	ClearWebsite     bool `json:"clearWebsite"`     // Remove the website
+	ClearCoordinates bool `json:"clearCoordinates"` // Remove latitude and longitude
+	ClearCapacity    bool `json:"clearCapacity"`    // Remove the capacity limit, confirming every waitlisted user
 }
 
 // RegisterToConferenceRequest represents the payload for registering a user to a conference.
 // ConferenceID and Role are required fields.
 type RegisterToConferenceRequest struct {
-	ConferenceID string  `json:"conferenceId"` // UUID of the conference to register to
-	Role         string  `json:"role"`         // User's role: "attendee", "speaker", or "volunteer"
-	Notes        *string `json:"notes"`        // Optional notes about the registration
-	NeedsRide    bool    `json:"needsRide"`    // Whether user needs transportation to the conference
-	HasCar       bool    `json:"hasCar"`       // Whether user can provide transportation to others
+	ConferenceID string  `json:"conferenceId"`              // Deprecated: the conference is taken from the URL
+	Role         string  `json:"role" validate:"role"`      // User's role, "attendee" if omitted
+	Notes        *string `json:"notes" validate:"max=2000"` // Optional notes about the registration
+	NeedsRide    bool    `json:"needsRide"`                 // Whether user needs transportation to the conference
+	HasCar       bool    `json:"hasCar"`                    // Whether user can provide transportation to others
+}
+
+// CreateRideOfferRequest represents the payload for offering seats in a car to a conference.
+// Seats and DepartureTime are required; DepartureCity defaults to the driver's city.
+type CreateRideOfferRequest struct {
+	Seats              int32    `json:"seats" validate:"min=1"`                         // Number of seats offered to passengers (required, > 0)
+	DepartureCity      *string  `json:"departureCity" validate:"notblank,max=100"`      // City the driver leaves from (defaults to the driver's city)
+	DepartureTime      string   `json:"departureTime" validate:"required,rfc3339"`      // Departure time in RFC3339 format (required)
+	DepartureLatitude  *float64 `json:"departureLatitude" validate:"min=-90,max=90"`    // Optional GPS latitude of the departure point
+	DepartureLongitude *float64 `json:"departureLongitude" validate:"min=-180,max=180"` // Optional GPS longitude of the departure point
+	Notes              *string  `json:"notes" validate:"max=2000"`                      // Optional notes for passengers
+}
+
+// CreateRideRequestRequest represents the payload for asking a ride to a conference.
+// All fields are optional: the pickup city defaults to the user's city.
+type CreateRideRequestRequest struct {
+	PickupCity      *string  `json:"pickupCity" validate:"notblank,max=100"`      // Optional city the passenger leaves from
+	PickupLatitude  *float64 `json:"pickupLatitude" validate:"min=-90,max=90"`    // Optional GPS latitude of the pickup point
+	PickupLongitude *float64 `json:"pickupLongitude" validate:"min=-180,max=180"` // Optional GPS longitude of the pickup point
+	Notes           *string  `json:"notes" validate:"max=2000"`                   // Optional notes for drivers
+}
+
+// SetAdminRequest represents the payload for granting or revoking platform administrator rights.
+type SetAdminRequest struct {
+	IsAdmin bool `json:"isAdmin"` // Whether the user should be a platform administrator
 }
 
 // ErrorResponse represents a standard API error response.
-// It provides a human-readable error message to the client.
+// Clients should branch on Code, which is stable, and show Error to users.
 type ErrorResponse struct {
-	Error string `json:"error"` // Human-readable error message
+	Error     string       `json:"error"`               // Human-readable error message
+	Code      string       `json:"code"`                // Stable machine-readable error code, e.g. "conference_not_found"
+	Details   []FieldError `json:"details,omitempty"`   // Field-level validation errors, if any
+	RequestID string       `json:"requestId,omitempty"` // ID of the request, to correlate with server logs
+}
+
+// FieldError describes why a single request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`   // JSON name of the invalid field or query parameter
+	Code    string `json:"code"`    // Machine-readable reason, e.g. "required" or "too_long"
+	Message string `json:"message"` // Human-readable reason
 }
 
 // LoginResponse is returned after successful user authentication.
@@ -88,6 +174,20 @@ type ConferenceResponse struct {
 	Latitude  *float64 `json:"latitude,omitempty"`  // Optional GPS latitude coordinate
 	Longitude *float64 `json:"longitude,omitempty"` // Optional GPS longitude coordinate
 	CreatedBy string   `json:"created_by"`          // User UUID who created the conference
+	Capacity  *int32   `json:"capacity,omitempty"`  // Optional maximum number of confirmed registrations
+}
+
+// NearbyConferenceResponse is a conference returned by the nearby search, with its distance from the requested point.
+type NearbyConferenceResponse struct {
+	ConferenceResponse
+	DistanceKm float64 `json:"distanceKm"` // Great-circle distance from the requested point in kilometres
+}
+
+// ConferenceListResponse is a page of conferences.
+// Next is the URL of the following page and is omitted on the last page.
+type ConferenceListResponse struct {
+	Data []ConferenceResponse `json:"data"`           // Conferences in the current page
+	Next *string              `json:"next,omitempty"` // URL of the next page (null on the last page)
 }
 
 // ConferenceWithAttendees represents a conference with its full list of registered participants.
@@ -100,6 +200,7 @@ type ConferenceWithAttendees struct {
 	Website   *string    `json:"website,omitempty"`   // Optional conference website URL
 	Latitude  *float64   `json:"latitude,omitempty"`  // Optional GPS latitude coordinate
 	Longitude *float64   `json:"longitude,omitempty"` // Optional GPS longitude coordinate
+	Capacity  *int32     `json:"capacity,omitempty"`  // Optional maximum number of confirmed registrations
 	Attendees []Attendee `json:"attendees"`           // List of all registered attendees
 }
 
@@ -107,6 +208,7 @@ type ConferenceWithAttendees struct {
 // This includes public user data and transportation preferences.
 type Attendee struct {
 	User      UserResponse `json:"user"`      // Public user information
+	Status    string       `json:"status"`    // Registration status ("registered" or "waitlist")
 	NeedsRide *bool        `json:"needsRide"` // Whether attendee needs transportation
 	HasCar    *bool        `json:"hasCar"`    // Whether attendee can provide transportation
 }
@@ -122,6 +224,10 @@ type UserResponse struct {
 	AvatarURL *string `json:"avatarUrl,omitempty"` // Optional avatar URL
 	Bio       *string `json:"bio,omitempty"`       // Optional biography
 	CreatedAt string  `json:"createdAt"`           // Creation timestamp
+	IsAdmin   bool    `json:"isAdmin,omitempty"`   // Whether the user is a platform administrator
+	// Whether the user confirmed the email address; unverified users cannot create
+	// conferences or register to them
+	EmailVerified bool `json:"emailVerified"`
 }
 
 // RegistrationResponse represents a conference registration in API responses.
@@ -145,5 +251,122 @@ type TokenResponse struct {
 	ID         string  `json:"id"`                   // Token UUID
 	CreatedAt  string  `json:"createdAt"`            // Token creation timestamp in RFC3339 format
 	LastUsedAt *string `json:"lastUsedAt,omitempty"` // Last usage timestamp in RFC3339 format (null if never used)
+	ExpiresAt  string  `json:"expiresAt"`            // Expiry timestamp in RFC3339 format if not used again
 	Revoked    bool    `json:"revoked"`              // Whether the token has been revoked
+	Current    bool    `json:"current"`              // Whether this is the token used for the current request
+}
+
+// RevokeOtherTokensResponse is returned after revoking all sessions except the current one.
+type RevokeOtherTokensResponse struct {
+	Revoked int64 `json:"revoked"` // Number of tokens that have been revoked
+}
+
+// ConferenceRegistrationResponse represents a registration as seen by the conference organizers.
+// Unlike Attendee it includes the registration status, role and notes.
+type ConferenceRegistrationResponse struct {
+	ID           string       `json:"id"`                    // Registration UUID
+	User         UserResponse `json:"user"`                  // Registered user
+	Status       string       `json:"status"`                // Registration status
+	Role         string       `json:"role"`                  // User's role at the conference
+	Notes        *string      `json:"notes,omitempty"`       // Optional notes left by the user
+	NeedsRide    *bool        `json:"needsRide"`             // Whether user needs transportation
+	HasCar       *bool        `json:"hasCar"`                // Whether user can provide transportation
+	RegisteredAt string       `json:"registeredAt"`          // Registration timestamp in RFC3339 format
+	CancelledAt  *string      `json:"cancelledAt,omitempty"` // Cancellation timestamp in RFC3339 format (null if active)
+}
+
+// RideOfferResponse represents a driver's ride offer in API responses.
+type RideOfferResponse struct {
+	ID                 string           `json:"id"`                           // Ride offer UUID
+	ConferenceID       string           `json:"conferenceId"`                 // Conference UUID
+	Driver             *RideParticipant `json:"driver,omitempty"`             // Driver information (only in listings)
+	DriverID           string           `json:"driverId"`                     // Driver user UUID
+	Seats              int32            `json:"seats"`                        // Total seats offered
+	SeatsAvailable     int32            `json:"seatsAvailable"`               // Seats not yet reserved
+	DepartureCity      string           `json:"departureCity"`                // City the driver leaves from
+	DepartureTime      string           `json:"departureTime"`                // Departure time in RFC3339 format
+	DepartureLatitude  *float64         `json:"departureLatitude,omitempty"`  // Optional GPS latitude of the departure point
+	DepartureLongitude *float64         `json:"departureLongitude,omitempty"` // Optional GPS longitude of the departure point
+	Notes              *string          `json:"notes,omitempty"`              // Optional notes for passengers
+}
+
+// RideRequestResponse represents a passenger's ride request in API responses.
+type RideRequestResponse struct {
+	ID              string           `json:"id"`                        // Ride request UUID
+	ConferenceID    string           `json:"conferenceId"`              // Conference UUID
+	Passenger       *RideParticipant `json:"passenger,omitempty"`       // Passenger information (only in listings)
+	PassengerID     string           `json:"passengerId"`               // Passenger user UUID
+	OfferID         *string          `json:"offerId,omitempty"`         // Ride offer UUID the request is attached to
+	Status          string           `json:"status"`                    // "open", "pending", "accepted", "declined" or "cancelled"
+	PickupCity      *string          `json:"pickupCity,omitempty"`      // Optional city the passenger leaves from
+	PickupLatitude  *float64         `json:"pickupLatitude,omitempty"`  // Optional GPS latitude of the pickup point
+	PickupLongitude *float64         `json:"pickupLongitude,omitempty"` // Optional GPS longitude of the pickup point
+	Notes           *string          `json:"notes,omitempty"`           // Optional notes for drivers
+	UpdatedAt       *string          `json:"updatedAt,omitempty"`       // Last status change in RFC3339 format
+}
+
+// RideMatchResponse is a ride offer ranked for a passenger's request.
+type RideMatchResponse struct {
+	Offer      RideOfferResponse `json:"offer"`                // Matching ride offer
+	SameCity   bool              `json:"sameCity"`             // Whether the driver leaves from the passenger's city
+	DistanceKm *float64          `json:"distanceKm,omitempty"` // Distance between pickup and departure (null if coordinates are missing)
+}
+
+// RideParticipant is the public information about a driver or passenger.
+// The email is only shared between a driver and a passenger once the ride is accepted.
+type RideParticipant struct {
+	ID       string  `json:"id"`                 // User UUID
+	Name     string  `json:"name"`               // User full name
+	Nickname *string `json:"nickname,omitempty"` // Optional display nickname
+	City     *string `json:"city,omitempty"`     // Optional city location
+	Email    *string `json:"email,omitempty"`    // User email (only for accepted rides)
+}
+
+// RideUser is a registered user flagged as needing or offering a ride.
+type RideUser struct {
+	User  RideParticipant `json:"user"`            // Public user information
+	Notes *string         `json:"notes,omitempty"` // Optional registration notes
+}
+
+// RidesOverviewResponse summarises carpooling for a conference.
+type RidesOverviewResponse struct {
+	Offers       []RideOfferResponse `json:"offers"`       // Ride offers published by drivers
+	NeedingRide  []RideUser          `json:"needingRide"`  // Attendees who flagged they need a ride
+	OfferingRide []RideUser          `json:"offeringRide"` // Attendees who flagged they have a car
+}
+
+// ConferenceStatsResponse contains aggregated registration figures for a conference.
+type ConferenceStatsResponse struct {
+	ConferenceID       string `json:"conferenceId"`             // Conference UUID
+	Title              string `json:"title"`                    // Conference title
+	Capacity           *int32 `json:"capacity,omitempty"`       // Maximum confirmed registrations (null if unlimited)
+	AvailableSeats     *int64 `json:"availableSeats,omitempty"` // Remaining seats (null if unlimited)
+	TotalRegistrations int64  `json:"totalRegistrations"`       // Active registrations (confirmed, waitlisted or attended)
+	ConfirmedCount     int64  `json:"confirmedCount"`           // Registrations with status "registered"
+	WaitlistCount      int64  `json:"waitlistCount"`            // Registrations on the waitlist
+	CancelledCount     int64  `json:"cancelledCount"`           // Registrations cancelled by their users
+	NeedingRideCount   int64  `json:"needingRideCount"`         // Active registrations needing a ride
+	OfferingRideCount  int64  `json:"offeringRideCount"`        // Active registrations offering a ride
+}
+
+// HealthResponse is returned by /livez and /readyz.
+type HealthResponse struct {
+	Status string                 `json:"status"`           // "ok" or "fail"
+	Checks map[string]CheckResult `json:"checks,omitempty"` // Readiness checks by name (only /readyz)
+}
+
+// CheckResult is the outcome of a single readiness check.
+type CheckResult struct {
+	Status     string `json:"status"`            // "ok" or "fail"
+	DurationMs int64  `json:"durationMs"`        // Time spent running the check
+	Error      string `json:"error,omitempty"`   // Failure reason
+	Details    any    `json:"details,omitempty"` // Check-specific data, e.g. pool usage
+}
+
+// PoolStats is the connection pool usage reported by the pool readiness check.
+type PoolStats struct {
+	InUse     int   `json:"inUse"`     // Connections currently in use
+	Idle      int   `json:"idle"`      // Idle connections
+	MaxOpen   int   `json:"maxOpen"`   // Connection limit (0 if unlimited)
+	WaitCount int64 `json:"waitCount"` // Total times a request waited for a connection
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"database/sql"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/marco-introini/conferenze.tech/backend/db"
@@ -18,6 +20,24 @@ func toUserResponse(u db.User) UserResponse {
 		AvatarURL: stringPtr(u.AvatarUrl),
 		Bio:       stringPtr(u.Bio),
 		CreatedAt: u.CreatedAt.Time.Format(time.RFC3339),
+		IsAdmin:   u.IsAdmin,
+
+		EmailVerified: u.EmailVerifiedAt.Valid,
+	}
+}
+
+// toConferenceResponse converts a database conference to the API response format
+func toConferenceResponse(c db.Conference) ConferenceResponse {
+	return ConferenceResponse{
+		ID:        c.ID.String(),
+		Title:     c.Title,
+		Date:      c.Date.Format(time.RFC3339),
+		Location:  c.Location,
+		Website:   stringPtr(c.Website),
+		Latitude:  float64Ptr(c.Latitude),
+		Longitude: float64Ptr(c.Longitude),
+		CreatedBy: c.CreatedBy.String(),
+		Capacity:  int32Ptr(c.Capacity),
 	}
 }
 
@@ -37,6 +57,22 @@ func nullFloat64(f *float64) sql.NullFloat64 {
 	return sql.NullFloat64{Float64: *f, Valid: true}
 }
 
+// nullTime converts a time pointer to sql.NullTime
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// nullInt32 converts an int32 pointer to sql.NullInt32
+func nullInt32(i *int32) sql.NullInt32 {
+	if i == nil {
+		return sql.NullInt32{Valid: false}
+	}
+	return sql.NullInt32{Int32: *i, Valid: true}
+}
+
 // nullBool converts a bool to sql.NullBool
 func nullBool(b bool) sql.NullBool {
 	return sql.NullBool{Bool: b, Valid: true}
@@ -58,6 +94,14 @@ func float64Ptr(f sql.NullFloat64) *float64 {
 	return &f.Float64
 }
 
+// int32Ptr converts sql.NullInt32 to an int32 pointer
+func int32Ptr(i sql.NullInt32) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int32
+}
+
 // boolPtr converts sql.NullBool to a bool pointer
 func boolPtr(b sql.NullBool) *bool {
 	if !b.Valid {
@@ -74,3 +118,21 @@ func timePtr(t sql.NullTime) *string {
 	s := t.Time.Format(time.RFC3339)
 	return &s
 }
+
+// etag builds a strong ETag from an updated_at timestamp (microsecond precision, as stored by Postgres)
+func etag(t sql.NullTime) string {
+	return `"` + strconv.FormatInt(t.Time.UnixMicro(), 10) + `"`
+}
+
+// parseETag extracts the updated_at timestamp from an ETag produced by etag
+func parseETag(tag string) (time.Time, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return time.Time{}, false
+	}
+	micros, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMicro(micros), true
+}
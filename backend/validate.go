@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Field error codes returned in FieldError.Code
+const (
+	FieldRequired     = "required"
+	FieldBlank        = "blank"
+	FieldTooShort     = "too_short"
+	FieldTooLong      = "too_long"
+	FieldOutOfRange   = "out_of_range"
+	FieldInvalidEmail = "invalid_email"
+	FieldInvalidURL   = "invalid_url"
+	FieldInvalidDate  = "invalid_date"
+	FieldNotAllowed   = "not_allowed"
+	FieldWeakPassword = "weak_password"
+)
+
+// maxPasswordBytes is the bcrypt input limit: longer passwords would be silently truncated
+const maxPasswordBytes = 72
+
+// minPasswordLength is the minimum number of characters of a password
+const minPasswordLength = 8
+
+// structValidator is implemented by request types with checks spanning several fields.
+// It runs after the field rules, only if they all passed.
+type structValidator interface {
+	validate() []FieldError
+}
+
+// validateRequest checks the `validate` struct tags of req, a struct or pointer to struct.
+// Rules are comma-separated; on pointer fields they apply to the pointed value when set.
+//
+//	required  the field must be present and not blank
+//	notblank  if present, the string must not be blank
+//	min=N     minimum string length in characters, or minimum number
+//	max=N     maximum string length in characters, or maximum number
+//	email     a plain email address (no display name)
+//	url       an absolute http or https URL
+//	rfc3339   a timestamp in RFC 3339 format
+//	oneof=a b the value must be one of the space-separated options
+//	role      one of the conference roles in ValidRoles
+//	password  at least 8 characters, at most 72 bytes, with letters and digits or symbols
+func validateRequest(req any) []FieldError {
+	v := reflect.Indirect(reflect.ValueOf(req))
+	t := v.Type()
+
+	var errs []FieldError
+	for i := 0; i < t.NumField(); i++ {
+		rules := t.Field(i).Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		if fe, ok := validateField(jsonName(t.Field(i)), v.Field(i), rules); !ok {
+			errs = append(errs, fe)
+		}
+	}
+
+	if sv, ok := v.Interface().(structValidator); ok && len(errs) == 0 {
+		errs = sv.validate()
+	}
+	return errs
+}
+
+// validateField applies rules to a single field and returns the first failure
+func validateField(name string, field reflect.Value, rules string) (FieldError, bool) {
+	fail := func(code, format string, args ...any) (FieldError, bool) {
+		return FieldError{Field: name, Code: code, Message: fmt.Sprintf(format, args...)}, false
+	}
+
+	list := strings.Split(rules, ",")
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			for _, rule := range list {
+				if rule == "required" {
+					return fail(FieldRequired, "is required")
+				}
+			}
+			return FieldError{}, true
+		}
+		field = field.Elem()
+	}
+
+	for _, rule := range list {
+		rule, arg, _ := strings.Cut(rule, "=")
+		switch rule {
+		case "required":
+			if field.IsZero() || (field.Kind() == reflect.String && strings.TrimSpace(field.String()) == "") {
+				return fail(FieldRequired, "is required")
+			}
+		case "notblank":
+			if strings.TrimSpace(field.String()) == "" {
+				return fail(FieldBlank, "cannot be blank")
+			}
+		case "min", "max":
+			if fe, ok := checkBound(name, field, rule, arg); !ok {
+				return fe, false
+			}
+		case "email":
+			if s := field.String(); s != "" {
+				addr, err := mail.ParseAddress(s)
+				if err != nil || addr.Address != s {
+					return fail(FieldInvalidEmail, "must be a valid email address")
+				}
+			}
+		case "url":
+			if s := field.String(); s != "" {
+				u, err := url.Parse(s)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fail(FieldInvalidURL, "must be an absolute http or https URL")
+				}
+			}
+		case "rfc3339":
+			if s := field.String(); s != "" {
+				if _, err := time.Parse(time.RFC3339, s); err != nil {
+					return fail(FieldInvalidDate, "must be a date in RFC 3339 format, e.g. 2026-05-10T09:00:00Z")
+				}
+			}
+		case "oneof":
+			if s := field.String(); s != "" && !slices.Contains(strings.Fields(arg), s) {
+				return fail(FieldNotAllowed, "must be one of: %s", strings.Join(strings.Fields(arg), ", "))
+			}
+		case "role":
+			if s := field.String(); s != "" && !IsValidRole(s) {
+				return fail(FieldNotAllowed, "must be one of: %s", strings.Join(ValidRoles, ", "))
+			}
+		case "password":
+			if fe, ok := checkPassword(name, field.String()); !ok {
+				return fe, false
+			}
+		default:
+			panic(fmt.Sprintf("unknown validation rule %q on field %s", rule, name))
+		}
+	}
+	return FieldError{}, true
+}
+
+// checkBound applies a min or max rule to a string length or a number
+func checkBound(name string, field reflect.Value, rule, arg string) (FieldError, bool) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid %s=%s on field %s", rule, arg, name))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		length := utf8.RuneCountInString(field.String())
+		if rule == "min" && float64(length) < limit {
+			return FieldError{Field: name, Code: FieldTooShort, Message: fmt.Sprintf("must be at least %s characters", arg)}, false
+		}
+		if rule == "max" && float64(length) > limit {
+			return FieldError{Field: name, Code: FieldTooLong, Message: fmt.Sprintf("must be at most %s characters", arg)}, false
+		}
+	default:
+		var n float64
+		if field.CanInt() {
+			n = float64(field.Int())
+		} else {
+			n = field.Float()
+		}
+		if (rule == "min" && !(n >= limit)) || (rule == "max" && !(n <= limit)) {
+			word := map[string]string{"min": "at least", "max": "at most"}[rule]
+			return FieldError{Field: name, Code: FieldOutOfRange, Message: fmt.Sprintf("must be %s %s", word, arg)}, false
+		}
+	}
+	return FieldError{}, true
+}
+
+// checkPassword enforces the password strength rules
+func checkPassword(name, password string) (FieldError, bool) {
+	var letters, others bool
+	for _, c := range password {
+		if unicode.IsLetter(c) {
+			letters = true
+		} else {
+			others = true
+		}
+	}
+
+	switch {
+	case utf8.RuneCountInString(password) < minPasswordLength:
+		return FieldError{Field: name, Code: FieldWeakPassword, Message: fmt.Sprintf("must be at least %d characters", minPasswordLength)}, false
+	case len(password) > maxPasswordBytes:
+		return FieldError{Field: name, Code: FieldTooLong, Message: fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)}, false
+	case !letters || !others:
+		return FieldError{Field: name, Code: FieldWeakPassword, Message: "must contain letters and at least one digit or symbol"}, false
+	}
+	return FieldError{}, true
+}
+
+// jsonName returns the JSON name of a struct field
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// writeValidationError writes a 422 response listing the invalid fields
+func writeValidationError(w http.ResponseWriter, errs []FieldError) {
+	writeAPIError(w, &APIError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    CodeValidationFailed,
+		Message: "Validation failed",
+		Details: errs,
+	})
+}
+
+// coordinateErrors reports a latitude or longitude given without the other
+func coordinateErrors(latField string, lat *float64, lngField string, lng *float64) []FieldError {
+	switch {
+	case lat != nil && lng == nil:
+		return []FieldError{{Field: lngField, Code: FieldRequired, Message: "is required when " + latField + " is set"}}
+	case lat == nil && lng != nil:
+		return []FieldError{{Field: latField, Code: FieldRequired, Message: "is required when " + lngField + " is set"}}
+	}
+	return nil
+}
+
+// validate checks that coordinates are given in pairs
+func (r CreateConferenceRequest) validate() []FieldError {
+	return coordinateErrors("latitude", r.Latitude, "longitude", r.Longitude)
+}
+
+// validate checks that coordinates are given in pairs and that no field is
+// both set and cleared
+func (r UpdateConferenceRequest) validate() []FieldError {
+	if errs := coordinateErrors("latitude", r.Latitude, "longitude", r.Longitude); errs != nil {
+		return errs
+	}
+
+	var errs []FieldError
+	conflict := func(field string, set bool, clearField string, clear bool) {
+		if set && clear {
+			errs = append(errs, FieldError{Field: clearField, Code: FieldNotAllowed, Message: "cannot be used together with " + field})
+		}
+	}
+	conflict("website", r.Website != nil, "clearWebsite", r.ClearWebsite)
+	conflict("latitude", r.Latitude != nil, "clearCoordinates", r.ClearCoordinates)
+	conflict("capacity", r.Capacity != nil, "clearCapacity", r.ClearCapacity)
+	return errs
+}
+
+// validate checks that coordinates are given in pairs
+func (r CreateRideOfferRequest) validate() []FieldError {
+	return coordinateErrors("departureLatitude", r.DepartureLatitude, "departureLongitude", r.DepartureLongitude)
+}
+
+// validate checks that coordinates are given in pairs
+func (r CreateRideRequestRequest) validate() []FieldError {
+	return coordinateErrors("pickupLatitude", r.PickupLatitude, "pickupLongitude", r.PickupLongitude)
+}
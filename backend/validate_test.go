@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fieldCodes restituisce gli errori come mappa campo -> codice
+func fieldCodes(errs []FieldError) map[string]string {
+	codes := make(map[string]string, len(errs))
+	for _, e := range errs {
+		codes[e.Field] = e.Code
+	}
+	return codes
+}
+
+// Test validazione della registrazione utente
+func TestValidateRegisterRequest(t *testing.T) {
+	str := func(s string) *string { return &s }
+	valid := RegisterRequest{Email: "mario@example.com", Password: "gopher2026", Name: "Mario Rossi"}
+
+	tests := []struct {
+		name   string
+		modify func(r *RegisterRequest)
+		want   map[string]string
+	}{
+		{"Valid request", func(r *RegisterRequest) {}, map[string]string{}},
+		{"Missing fields", func(r *RegisterRequest) { *r = RegisterRequest{} }, map[string]string{
+			"email": FieldRequired, "password": FieldRequired, "name": FieldRequired,
+		}},
+		{"Blank name", func(r *RegisterRequest) { r.Name = "   " }, map[string]string{"name": FieldRequired}},
+		{"Invalid email", func(r *RegisterRequest) { r.Email = "mario@" }, map[string]string{"email": FieldInvalidEmail}},
+		{"Email with display name", func(r *RegisterRequest) { r.Email = "Mario <mario@example.com>" }, map[string]string{"email": FieldInvalidEmail}},
+		{"Short password", func(r *RegisterRequest) { r.Password = "go2026" }, map[string]string{"password": FieldWeakPassword}},
+		{"Letters only password", func(r *RegisterRequest) { r.Password = "gophergopher" }, map[string]string{"password": FieldWeakPassword}},
+		{"Password above bcrypt limit", func(r *RegisterRequest) { r.Password = strings.Repeat("go1", 25) }, map[string]string{"password": FieldTooLong}},
+		{"Name too long", func(r *RegisterRequest) { r.Name = strings.Repeat("a", 256) }, map[string]string{"name": FieldTooLong}},
+		{"Multibyte name at limit", func(r *RegisterRequest) { r.Name = strings.Repeat("è", 255) }, map[string]string{}},
+		{"Invalid avatar URL", func(r *RegisterRequest) { r.AvatarURL = str("javascript:alert(1)") }, map[string]string{"avatarUrl": FieldInvalidURL}},
+		{"Blank city", func(r *RegisterRequest) { r.City = str("") }, map[string]string{"city": FieldBlank}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			got := fieldCodes(validateRequest(req))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected errors %v, got %v", tt.want, got)
+			}
+			for field, code := range tt.want {
+				if got[field] != code {
+					t.Errorf("Field %s: expected code %q, got %q", field, code, got[field])
+				}
+			}
+		})
+	}
+}
+
+// Test validazione di conferenze, profilo, iscrizioni e passaggi
+func TestValidateOtherRequests(t *testing.T) {
+	str := func(s string) *string { return &s }
+	coord := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name string
+		req  any
+		want map[string]string
+	}{
+		{"Valid conference", CreateConferenceRequest{Title: "GoLab", Date: "2026-11-10T09:00:00Z", Location: "Firenze"}, map[string]string{}},
+		{"Conference without title and date", CreateConferenceRequest{Location: "Firenze"}, map[string]string{"title": FieldRequired, "date": FieldRequired}},
+		{"Conference with date only", CreateConferenceRequest{Title: "GoLab", Date: "2026-11-10", Location: "Firenze"}, map[string]string{"date": FieldInvalidDate}},
+		{"Conference with latitude only", CreateConferenceRequest{Title: "GoLab", Date: "2026-11-10T09:00:00Z", Location: "Firenze", Latitude: coord(43.77)}, map[string]string{"longitude": FieldRequired}},
+		{"Conference with longitude out of range", CreateConferenceRequest{Title: "GoLab", Date: "2026-11-10T09:00:00Z", Location: "Firenze", Latitude: coord(0), Longitude: coord(181)}, map[string]string{"longitude": FieldOutOfRange}},
+		{"Update with blank title", UpdateConferenceRequest{Title: str(" ")}, map[string]string{"title": FieldBlank}},
+		{"Empty profile update", UpdateMeRequest{}, map[string]string{}},
+		{"Profile with long nickname", UpdateMeRequest{Nickname: str(strings.Repeat("n", 101))}, map[string]string{"nickname": FieldTooLong}},
+		{"Registration without role", RegisterToConferenceRequest{}, map[string]string{}},
+		{"Registration with unknown role", RegisterToConferenceRequest{Role: "sponsor"}, map[string]string{"role": FieldNotAllowed}},
+		{"Valid ride offer", CreateRideOfferRequest{Seats: 3, DepartureTime: "2026-11-10T07:00:00Z"}, map[string]string{}},
+		{"Ride offer without seats and time", CreateRideOfferRequest{}, map[string]string{"seats": FieldOutOfRange, "departureTime": FieldRequired}},
+		{"Ride offer with long city", CreateRideOfferRequest{Seats: 1, DepartureTime: "2026-11-10T07:00:00Z", DepartureCity: str(strings.Repeat("c", 101))}, map[string]string{"departureCity": FieldTooLong}},
+		{"Ride offer with latitude only", CreateRideOfferRequest{Seats: 1, DepartureTime: "2026-11-10T07:00:00Z", DepartureLatitude: coord(45.46)}, map[string]string{"departureLongitude": FieldRequired}},
+		{"Ride request with blank city", CreateRideRequestRequest{PickupCity: str(" ")}, map[string]string{"pickupCity": FieldBlank}},
+		{"Ride request with long notes", CreateRideRequestRequest{Notes: str(strings.Repeat("n", 2001))}, map[string]string{"notes": FieldTooLong}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldCodes(validateRequest(tt.req))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected errors %v, got %v", tt.want, got)
+			}
+			for field, code := range tt.want {
+				if got[field] != code {
+					t.Errorf("Field %s: expected code %q, got %q", field, code, got[field])
+				}
+			}
+		})
+	}
+}
+
+// Test risposta 422 con i dettagli dei campi
+func TestWriteValidationError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	writeValidationError(rr, validateRequest(RegisterRequest{}))
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", rr.Code)
+	}
+	resp := decodeErrorResponse(t, rr)
+	if resp.Code != CodeValidationFailed || len(resp.Details) != 3 {
+		t.Errorf("Expected validation_failed with 3 field errors, got %+v", resp)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-introini/conferenze.tech/backend/db"
+)
+
+// Purposes of the account tokens: a token signed for one purpose is rejected for any other
+const purposeVerifyEmail = "verify-email"
+
+// Account token errors
+var (
+	errAccountTokenInvalid = errors.New("invalid account token")
+	errAccountTokenExpired = errors.New("account token expired")
+)
+
+// accountKey returns the HMAC key configured in cfg, or a random one.
+// Config.Validate only allows the random key when emails are not sent over SMTP.
+func accountKey(cfg AccountConfig) []byte {
+	if cfg.TokenSecret != "" {
+		return []byte(cfg.TokenSecret)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// accountToken is a token emailed to the owner of an account. It carries the
+// user ID and the expiry, signed with HMAC-SHA256 together with the purpose and
+// a binding, a value of the account (e.g. the email) that is not sent but
+// recomputed on verification: if the value changes, the token stops working.
+//
+// Encoding: base64url(user ID (16 bytes) || expiry (8 bytes, Unix seconds)) "." base64url(HMAC)
+type accountToken struct {
+	userID  uuid.UUID
+	expires time.Time
+	payload []byte
+	mac     []byte
+}
+
+// signAccountToken returns a token for purpose bound to userID and binding, valid until expires
+func (s *Server) signAccountToken(purpose string, userID uuid.UUID, binding string, expires time.Time) string {
+	payload := binary.BigEndian.AppendUint64(userID[:], uint64(expires.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.accountTokenMAC(purpose, payload, binding))
+}
+
+// accountTokenMAC signs payload for purpose and binding
+func (s *Server) accountTokenMAC(purpose string, payload []byte, binding string) []byte {
+	mac := hmac.New(sha256.New, s.accountKey)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	mac.Write([]byte(binding))
+	return mac.Sum(nil)
+}
+
+// parseAccountToken decodes token and checks its expiry; the signature is
+// checked by verifyAccountToken, once the account it refers to has been loaded
+func parseAccountToken(token string, now time.Time) (accountToken, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return accountToken{}, errAccountTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != 24 {
+		return accountToken{}, errAccountTokenInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || len(mac) != sha256.Size {
+		return accountToken{}, errAccountTokenInvalid
+	}
+
+	t := accountToken{
+		userID:  uuid.UUID(payload[:16]),
+		expires: time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0),
+		payload: payload,
+		mac:     mac,
+	}
+	if !now.Before(t.expires) {
+		return t, errAccountTokenExpired
+	}
+	return t, nil
+}
+
+// verifyAccountToken reports whether t was signed by this server for purpose and binding
+func (s *Server) verifyAccountToken(t accountToken, purpose, binding string) bool {
+	return hmac.Equal(t.mac, s.accountTokenMAC(purpose, t.payload, binding))
+}
+
+// accountLink returns the frontend link base with token as query parameter
+func accountLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// sendVerificationEmail emails user the link confirming their address
+func (s *Server) sendVerificationEmail(ctx context.Context, user db.User) error {
+	expires := time.Now().Add(s.cfg.Accounts.VerificationTTL)
+	token := s.signAccountToken(purposeVerifyEmail, user.ID, user.Email, expires)
+
+	return s.mailer.Send(ctx, Message{
+		To:      user.Email,
+		Subject: "Conferma il tuo indirizzo email su conferenze.tech",
+		Body: fmt.Sprintf("Ciao %s,\n\n"+
+			"per completare la registrazione su conferenze.tech conferma il tuo indirizzo email aprendo questo link:\n\n"+
+			"%s\n\n"+
+			"Il link scade il %s. Se non hai creato tu l'account, ignora questa email.\n",
+			user.Name, accountLink(s.cfg.Accounts.VerifyURL, token), expires.Format("02/01/2006 15:04 MST")),
+	})
+}
+
+// VerifyEmail confirms the email address of the account the token was sent to
+func (s *Server) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	var req VerifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if errs := validateRequest(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	token, err := parseAccountToken(req.Token, time.Now())
+	if errors.Is(err, errAccountTokenExpired) {
+		writeError(w, http.StatusBadRequest, CodeVerificationTokenExpired, "Verification link expired, request a new one")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidVerificationToken, "Invalid verification link")
+		return
+	}
+
+	user, err := s.db.GetUserByID(ctx, token.userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Internal server error")
+		return
+	}
+	if err != nil || !s.verifyAccountToken(token, purposeVerifyEmail, user.Email) {
+		writeError(w, http.StatusBadRequest, CodeInvalidVerificationToken, "Invalid verification link")
+		return
+	}
+
+	user, err = s.db.VerifyUserEmail(ctx, db.VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The email changed after the token was checked
+			writeError(w, http.StatusBadRequest, CodeInvalidVerificationToken, "Invalid verification link")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error verifying email", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to verify email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toUserResponse(user)); err != nil {
+		slog.WarnContext(r.Context(), "Failed to encode user response", "error", err)
+	}
+}
+
+// ResendVerificationEmail sends a new verification link to the authenticated user.
+// Resends share the per-email budget of login and registration attempts.
+func (s *Server) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.RequestTimeout)
+	defer cancel()
+
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting user", "error", err)
+		writeError(w, http.StatusNotFound, CodeUserNotFound, "User not found")
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		writeError(w, http.StatusConflict, CodeEmailAlreadyVerified, "Email already verified")
+		return
+	}
+
+	if !s.limitAuthAttempt(w, r, user.Email) {
+		return
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		slog.ErrorContext(r.Context(), "Error sending verification email", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to send verification email")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
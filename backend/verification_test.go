@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test firma e verifica dei token inviati via email
+func TestAccountToken(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Accounts.TokenSecret = strings.Repeat("k", 32)
+	s := NewServer(nil, cfg)
+	other := NewServer(nil, DefaultConfig())
+
+	userID := uuid.New()
+	now := time.Now()
+	valid := s.signAccountToken(purposeVerifyEmail, userID, "mario@example.com", now.Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		server  *Server
+		token   string
+		purpose string
+		binding string
+		parse   error
+		valid   bool
+	}{
+		{"Valid token", s, valid, purposeVerifyEmail, "mario@example.com", nil, true},
+		{"Email changed", s, valid, purposeVerifyEmail, "luigi@example.com", nil, false},
+		{"Other purpose", s, valid, "reset-password", "mario@example.com", nil, false},
+		{"Other secret", other, valid, purposeVerifyEmail, "mario@example.com", nil, false},
+		{"Expired", s, s.signAccountToken(purposeVerifyEmail, userID, "mario@example.com", now.Add(-time.Minute)),
+			purposeVerifyEmail, "mario@example.com", errAccountTokenExpired, false},
+		{"Tampered user", s, tamperUser(valid), purposeVerifyEmail, "mario@example.com", nil, false},
+		{"Missing signature", s, strings.Split(valid, ".")[0], purposeVerifyEmail, "mario@example.com", errAccountTokenInvalid, false},
+		{"Garbage", s, "not-a-token.at-all", purposeVerifyEmail, "mario@example.com", errAccountTokenInvalid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := parseAccountToken(tt.token, now)
+			if !errors.Is(err, tt.parse) {
+				t.Fatalf("Expected parse error %v, got %v", tt.parse, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := tt.server.verifyAccountToken(token, tt.purpose, tt.binding); got != tt.valid {
+				t.Errorf("Expected valid=%v, got %v", tt.valid, got)
+			}
+			if tt.valid && token.userID != userID {
+				t.Errorf("Expected user %s, got %s", userID, token.userID)
+			}
+		})
+	}
+}
+
+// tamperUser cambia l'utente del token mantenendo la firma originale
+func tamperUser(token string) string {
+	payload, mac, _ := strings.Cut(token, ".")
+	data, _ := base64.RawURLEncoding.DecodeString(payload)
+	data[0] ^= 0xff
+	return base64.RawURLEncoding.EncodeToString(data) + "." + mac
+}
+
+// Test link del frontend con il token come parametro
+func TestAccountLink(t *testing.T) {
+	link := accountLink("https://conferenze.tech/verifica-email?lang=it", "abc.def")
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Invalid link %q: %v", link, err)
+	}
+	if u.Path != "/verifica-email" || u.Query().Get("token") != "abc.def" || u.Query().Get("lang") != "it" {
+		t.Errorf("Unexpected link %q", link)
+	}
+}
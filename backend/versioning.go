@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiVersion is a version of the API mounted under its own prefix. Every
+// version has its own route table, so a breaking change to a DTO is made by
+// adding a version whose handlers return the new DTO, while the handlers of the
+// older versions keep returning the old one.
+type apiVersion struct {
+	prefix string
+	routes []route // patterns relative to prefix
+}
+
+// apiVersions returns the versions of the API, oldest first
+func (s *Server) apiVersions() []apiVersion {
+	return []apiVersion{
+		{prefix: v1Prefix, routes: s.v1Routes()},
+	}
+}
+
+const (
+	v1Prefix = "/api/v1"
+
+	// legacyPrefix serves the unversioned paths of the API before versioning was
+	// introduced, kept as deprecated aliases of version 1 until the sunset date
+	legacyPrefix = "/api"
+)
+
+var (
+	legacyDeprecatedAt = time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	legacySunset       = time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// legacyRoute is a deprecated alias of a route of version 1
+type legacyRoute struct {
+	route
+	successor string // pattern of the versioned route
+}
+
+// legacyRoutes returns the unversioned aliases of the version 1 routes
+func (s *Server) legacyRoutes() []legacyRoute {
+	v1 := s.v1Routes()
+	aliases := make([]legacyRoute, len(v1))
+	for i, rt := range v1 {
+		alias := rt
+		alias.pattern = prefixPattern(legacyPrefix, rt.pattern)
+		aliases[i] = legacyRoute{route: alias, successor: prefixPattern(v1Prefix, rt.pattern)}
+	}
+	return aliases
+}
+
+// deprecationMiddleware announces on every response of a legacy alias, errors
+// included, that it is deprecated (Deprecation, RFC 9745) and when it is
+// removed (Sunset, RFC 8594), and links the same path under version 1
+func deprecationMiddleware(next http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", legacyDeprecatedAt.Unix())
+	sunset := legacySunset.Format(http.TimeFormat)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor := v1Prefix + strings.TrimPrefix(r.URL.EscapedPath(), legacyPrefix)
+		h := w.Header()
+		h.Set("Deprecation", deprecation)
+		h.Set("Sunset", sunset)
+		h.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// prefixPattern mounts the mux pattern "METHOD /path" under prefix
+func prefixPattern(prefix, pattern string) string {
+	method, path, _ := strings.Cut(pattern, " ")
+	return method + " " + prefix + path
+}
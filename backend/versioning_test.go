@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test header di deprecazione sui path legacy e assenza sui path versionati
+func TestLegacyRoutesDeprecation(t *testing.T) {
+	handler := NewServer(nil, DefaultConfig()).Handler()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		status     int
+		deprecated bool
+		successor  string
+	}{
+		{"Versioned route", "GET", "/api/v1/me", http.StatusUnauthorized, false, ""},
+		{"Legacy alias", "GET", "/api/me", http.StatusUnauthorized, true, "</api/v1/me>; rel=\"successor-version\""},
+		{"Legacy alias with wildcard", "DELETE", "/api/rides/offers/abc", http.StatusUnauthorized, true, "</api/v1/rides/offers/abc>; rel=\"successor-version\""},
+		{"Unversioned route", "GET", "/livez", http.StatusOK, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			deprecation, sunset := rr.Header().Get("Deprecation"), rr.Header().Get("Sunset")
+			if tt.deprecated != (deprecation != "") || tt.deprecated != (sunset != "") {
+				t.Errorf("Expected deprecated=%v, got Deprecation %q and Sunset %q", tt.deprecated, deprecation, sunset)
+			}
+			if tt.deprecated && !strings.HasPrefix(deprecation, "@") {
+				t.Errorf("Expected Deprecation as structured date, got %q", deprecation)
+			}
+			if got := rr.Header().Get("Link"); got != tt.successor {
+				t.Errorf("Expected Link %q, got %q", tt.successor, got)
+			}
+		})
+	}
+}
+
+// Test alias legacy per ogni route della versione 1
+func TestLegacyRoutesMirrorV1(t *testing.T) {
+	s := NewServer(nil, DefaultConfig())
+	v1 := s.v1Routes()
+	aliases := s.legacyRoutes()
+	if len(aliases) != len(v1) {
+		t.Fatalf("Expected %d aliases, got %d", len(v1), len(aliases))
+	}
+	for i, alias := range aliases {
+		if want := prefixPattern(legacyPrefix, v1[i].pattern); alias.pattern != want {
+			t.Errorf("Expected alias %s, got %s", want, alias.pattern)
+		}
+		if want := prefixPattern(v1Prefix, v1[i].pattern); alias.successor != want {
+			t.Errorf("Expected successor %s, got %s", want, alias.successor)
+		}
+		if alias.access != v1[i].access {
+			t.Errorf("Alias %s has access %d, expected %d", alias.pattern, alias.access, v1[i].access)
+		}
+	}
+}
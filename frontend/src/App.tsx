@@ -8,6 +8,9 @@ import Register from "./pages/Register";
 import Profilo from "./pages/Profilo";
 import MieConferenze from "./pages/MieConferenze";
 import RegistrazioneConferenza from "./pages/RegistrazioneConferenza";
+import VerificaEmail from "./pages/VerificaEmail";
+import PasswordDimenticata from "./pages/PasswordDimenticata";
+import ReimpostaPassword from "./pages/ReimpostaPassword";
 import type { Conference } from "./types";
 import { Map, Calendar, Users, Grid, Search } from "lucide-react";
 import { AuthProvider, useAuth } from "./AuthContext";
@@ -27,8 +30,14 @@ function Home() {
   useEffect(() => {
     const loadConferences = async () => {
       try {
-        const data = await api.getConferences();
-        setConferences(data);
+        // Search and map work on the full list, so follow the pages to the end
+        let page = await api.getConferences({ limit: 100 });
+        const all = [...page.data];
+        while (page.next) {
+          page = await api.getConferencesPage(page.next);
+          all.push(...page.data);
+        }
+        setConferences(all);
       } catch (err) {
         console.error("Failed to load conferences:", err);
         setError("Impossibile caricare le conferenze");
@@ -263,6 +272,9 @@ function App() {
           <Route path="/profilo" element={<Profilo />} />
           <Route path="/mie-conferenze" element={<MieConferenze />} />
           <Route path="/conferenze/:conferenceId/registrazione" element={<RegistrazioneConferenza />} />
+          <Route path="/verifica-email" element={<VerificaEmail />} />
+          <Route path="/password-dimenticata" element={<PasswordDimenticata />} />
+          <Route path="/reimposta-password" element={<ReimpostaPassword />} />
         </Routes>
       </AuthProvider>
     </BrowserRouter>
@@ -1,4 +1,6 @@
 const API_URL = import.meta.env.VITE_API_URL || "http://localhost:8080";
+// Versioned API prefix; the unversioned /api paths are deprecated
+const API_BASE = "/api/v1";
 
 export interface User {
   id: string;
@@ -9,6 +11,7 @@ export interface User {
   avatarUrl?: string;
   bio?: string;
   createdAt?: string;
+  emailVerified?: boolean;
 }
 
 export interface Conference {
@@ -22,6 +25,22 @@ export interface Conference {
   attendees?: Attendee[];
 }
 
+export interface ConferencePage {
+  data: Conference[];
+  next?: string;
+}
+
+export interface ConferenceFilters {
+  when?: "upcoming" | "past";
+  from?: string;
+  to?: string;
+  location?: string;
+  search?: string;
+  sort?: "date" | "-date";
+  limit?: number;
+  cursor?: string;
+}
+
 export interface Attendee {
   user: {
     id: string;
@@ -45,6 +64,25 @@ export interface Registration {
   registeredAt: string;
 }
 
+export interface FieldError {
+  field: string;
+  code: string;
+  message: string;
+}
+
+export class ApiError extends Error {
+  constructor(
+    message: string,
+    public status: number,
+    public code: string,
+    public details: FieldError[] = [],
+    public requestId?: string
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
 let authToken: string | null = null;
 
 export function setAuthToken(token: string | null) {
@@ -74,12 +112,12 @@ async function request<T>(
   console.log(`[API] ${options.method || 'GET'} ${endpoint}`, { status: response.status, ok: response.ok });
 
   if (!response.ok) {
-    const error = await response.json().catch(() => ({ error: "Errore" }));
+    const error = await response.json().catch(() => ({ error: "Errore", code: "unknown" }));
     console.error(`[API] Error response:`, error);
-    throw new Error(error.error || "Errore");
+    throw new ApiError(error.error || "Errore", response.status, error.code || "unknown", error.details, error.requestId);
   }
 
-  if (response.status === 204) {
+  if (response.status === 204 || response.status === 202) {
     return {} as T;
   }
 
@@ -88,7 +126,7 @@ async function request<T>(
 
 export const api = {
   login: (email: string, password: string) =>
-    request<{ user: User; token: string }>("/api/login", {
+    request<{ user: User; token: string }>(`${API_BASE}/login`, {
       method: "POST",
       body: JSON.stringify({ email, password }),
     }),
@@ -102,16 +140,58 @@ export const api = {
     avatarUrl?: string;
     bio?: string;
   }) =>
-    request<{ user: User; token: string }>("/api/register", {
+    request<{ user: User; token: string }>(`${API_BASE}/register`, {
       method: "POST",
       body: JSON.stringify(data),
     }),
 
-  getConferences: () =>
-    request<Conference[]>("/api/conferences", { method: "GET" }),
+  verifyEmail: (token: string) =>
+    request<User>(`${API_BASE}/verify-email`, {
+      method: "POST",
+      body: JSON.stringify({ token }),
+    }),
+
+  resendVerificationEmail: () =>
+    request<void>(`${API_BASE}/verify-email/resend`, { method: "POST" }),
+
+  forgotPassword: (email: string) =>
+    request<void>(`${API_BASE}/password/forgot`, {
+      method: "POST",
+      body: JSON.stringify({ email }),
+    }),
+
+  resetPassword: (token: string, password: string) =>
+    request<void>(`${API_BASE}/password/reset`, {
+      method: "POST",
+      body: JSON.stringify({ token, password }),
+    }),
+
+  changePassword: (currentPassword: string, newPassword: string) =>
+    request<void>(`${API_BASE}/me/password`, {
+      method: "PUT",
+      body: JSON.stringify({ currentPassword, newPassword }),
+    }),
+
+  getConferences: (filters: ConferenceFilters = {}) => {
+    const params = new URLSearchParams();
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value !== undefined && value !== "") params.set(key, String(value));
+    });
+    const query = params.toString();
+    return request<ConferencePage>(`${API_BASE}/conferences${query ? `?${query}` : ""}`, { method: "GET" });
+  },
+
+  getConferencesPage: (next: string) =>
+    request<ConferencePage>(next, { method: "GET" }),
+
+  getNearbyConferences: (lat: number, lng: number, radiusKm?: number) => {
+    const params = new URLSearchParams({ lat: String(lat), lng: String(lng) });
+    if (radiusKm !== undefined) params.set("radiusKm", String(radiusKm));
+    return request<(Conference & { distanceKm: number })[]>(`${API_BASE}/conferences/nearby?${params}`, { method: "GET" });
+  },
 
   getConference: (id: string) =>
-    request<Conference>(`/api/conferences/${id}`, { method: "GET" }),
+    request<Conference>(`${API_BASE}/conferences/${id}`, { method: "GET" }),
 
   createConference: (data: {
     title: string;
@@ -121,7 +201,7 @@ export const api = {
     latitude?: number;
     longitude?: number;
   }) =>
-    request<Conference>("/api/conferences", {
+    request<Conference>(`${API_BASE}/conferences`, {
       method: "POST",
       body: JSON.stringify(data),
     }),
@@ -135,19 +215,19 @@ export const api = {
       hasCar: boolean;
     }
   ) =>
-    request<Registration>(`/api/conferences/${conferenceId}/register`, {
+    request<Registration>(`${API_BASE}/conferences/${conferenceId}/register`, {
       method: "POST",
       body: JSON.stringify(data),
     }),
 
   getMyRegistrations: () =>
-    request<Registration[]>("/api/users/registrations", { method: "GET" }),
+    request<Registration[]>(`${API_BASE}/users/registrations`, { method: "GET" }),
 
   getMe: () =>
-    request<User>("/api/me", { method: "GET" }),
+    request<User>(`${API_BASE}/me`, { method: "GET" }),
 
   updateUser: (data: Partial<User>) =>
-    request<User>("/api/me", {
+    request<User>(`${API_BASE}/me`, {
       method: "PUT",
       body: JSON.stringify(data),
     }),
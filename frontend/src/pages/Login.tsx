@@ -84,9 +84,9 @@ export default function Login() {
                   <input type="checkbox" className="w-4 h-4 rounded border-slate-300 text-indigo-600 focus:ring-indigo-500" />
                   <span className="text-slate-600">Ricordami</span>
                 </label>
-                <a href="#" className="text-indigo-600 hover:text-indigo-700 font-medium">
+                <Link to="/password-dimenticata" className="text-indigo-600 hover:text-indigo-700 font-medium">
                   Password dimenticata?
-                </a>
+                </Link>
               </div>
 
               <button
@@ -1,8 +1,9 @@
 import { useState, useEffect } from "react";
 import { useNavigate } from "react-router-dom";
 import { useAuth } from "../AuthContext";
+import { api, ApiError } from "../api";
 import Layout from "../components/Layout";
-import { User, MapPin, Edit2, Save, X } from "lucide-react";
+import { User, MapPin, Edit2, Save, X, MailWarning, Lock } from "lucide-react";
 
 export default function Profilo() {
   const navigate = useNavigate();
@@ -14,6 +15,10 @@ export default function Profilo() {
     city: "",
     bio: "",
   });
+  const [verificationSent, setVerificationSent] = useState(false);
+  const [passwordForm, setPasswordForm] = useState({ current: "", next: "" });
+  const [passwordMessage, setPasswordMessage] = useState("");
+  const [passwordError, setPasswordError] = useState("");
 
   console.log("Profilo - user:", user);
   console.log("Profilo - isLoading:", isLoading);
@@ -67,9 +72,57 @@ export default function Profilo() {
     }
   };
 
+  const handleResendVerification = async () => {
+    try {
+      await api.resendVerificationEmail();
+      setVerificationSent(true);
+    } catch (error) {
+      console.error("Errore invio email di verifica:", error);
+      alert("Errore durante l'invio dell'email di verifica");
+    }
+  };
+
+  const handleChangePassword = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setPasswordMessage("");
+    setPasswordError("");
+    try {
+      await api.changePassword(passwordForm.current, passwordForm.next);
+      setPasswordForm({ current: "", next: "" });
+      setPasswordMessage("Password aggiornata. Le sessioni aperte su altri dispositivi sono state chiuse.");
+    } catch (error) {
+      if (error instanceof ApiError && error.code === "invalid_credentials") {
+        setPasswordError("La password attuale non è corretta");
+      } else if (error instanceof ApiError && error.details.length > 0) {
+        setPasswordError(error.details.map((d) => d.message).join(". "));
+      } else {
+        console.error("Errore cambio password:", error);
+        setPasswordError("Errore durante il cambio della password");
+      }
+    }
+  };
+
   return (
     <Layout>
       <div className="max-w-4xl mx-auto px-6 py-12">
+        {user.emailVerified === false && (
+          <div className="mb-6 p-4 bg-amber-50 border border-amber-200 rounded-xl flex items-center gap-3">
+            <MailWarning className="w-5 h-5 text-amber-600 flex-shrink-0" />
+            <p className="text-amber-800 text-sm flex-1">
+              {verificationSent
+                ? `Ti abbiamo inviato un nuovo link di verifica a ${user.email}`
+                : "Verifica il tuo indirizzo email per creare conferenze e registrarti agli eventi"}
+            </p>
+            {!verificationSent && (
+              <button
+                onClick={handleResendVerification}
+                className="text-sm font-medium text-amber-700 hover:text-amber-900"
+              >
+                Invia di nuovo
+              </button>
+            )}
+          </div>
+        )}
         <div className="bg-white rounded-3xl shadow-sm border border-slate-200 overflow-hidden">
           <div className="h-32 bg-gradient-to-r from-indigo-500 to-purple-600" />
           
@@ -189,6 +242,40 @@ export default function Profilo() {
             </div>
           </div>
         </div>
+
+        <div className="mt-6 bg-white rounded-3xl shadow-sm border border-slate-200 p-8">
+          <h2 className="text-lg font-semibold text-slate-900 mb-4 flex items-center gap-2">
+            <Lock className="w-5 h-5 text-slate-400" />
+            Cambia password
+          </h2>
+          {passwordMessage && <p className="mb-4 text-sm text-green-700">{passwordMessage}</p>}
+          {passwordError && <p className="mb-4 text-sm text-red-700">{passwordError}</p>}
+          <form onSubmit={handleChangePassword} className="grid grid-cols-1 md:grid-cols-2 gap-4">
+            <input
+              type="password"
+              value={passwordForm.current}
+              onChange={(e) => setPasswordForm({ ...passwordForm, current: e.target.value })}
+              placeholder="Password attuale"
+              className="w-full px-4 py-2 border border-slate-200 rounded-xl focus:outline-none focus:ring-2 focus:ring-indigo-500"
+              required
+            />
+            <input
+              type="password"
+              value={passwordForm.next}
+              onChange={(e) => setPasswordForm({ ...passwordForm, next: e.target.value })}
+              placeholder="Nuova password"
+              className="w-full px-4 py-2 border border-slate-200 rounded-xl focus:outline-none focus:ring-2 focus:ring-indigo-500"
+              required
+              minLength={8}
+            />
+            <button
+              type="submit"
+              className="md:col-span-2 justify-self-start px-4 py-2 bg-slate-900 text-white text-sm font-medium rounded-xl hover:bg-slate-800 transition-colors"
+            >
+              Aggiorna password
+            </button>
+          </form>
+        </div>
       </div>
     </Layout>
   );
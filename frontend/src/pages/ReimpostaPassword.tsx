@@ -0,0 +1,138 @@
+import { useState } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+import { Lock, ArrowRight, AlertCircle, CheckCircle } from "lucide-react";
+import Layout from "../components/Layout";
+import { api, ApiError } from "../api";
+
+export default function ReimpostaPassword() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token") ?? "";
+  const [password, setPassword] = useState("");
+  const [confirmPassword, setConfirmPassword] = useState("");
+  const [done, setDone] = useState(false);
+  const [error, setError] = useState(token ? "" : "Link per reimpostare la password non valido");
+  const [isLoading, setIsLoading] = useState(false);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError("");
+
+    if (password !== confirmPassword) {
+      setError("Le password non coincidono");
+      return;
+    }
+
+    setIsLoading(true);
+    try {
+      await api.resetPassword(token, password);
+      setDone(true);
+    } catch (err) {
+      if (err instanceof ApiError && err.code === "reset_token_expired") {
+        setError("Il link è scaduto: richiedine uno nuovo");
+      } else if (err instanceof ApiError && err.code === "invalid_reset_token") {
+        setError("Il link non è valido o è già stato usato");
+      } else if (err instanceof ApiError && err.details.length > 0) {
+        setError(err.details.map((d) => d.message).join(". "));
+      } else {
+        setError(err instanceof Error ? err.message : "Reimpostazione non riuscita");
+      }
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <Layout>
+      <main className="pt-32 pb-20 px-6">
+        <div className="max-w-md mx-auto">
+          <div className="text-center mb-10">
+            <h1 className="text-3xl font-bold text-slate-900 mb-3">Nuova password</h1>
+            <p className="text-slate-600">Scegli la nuova password del tuo account</p>
+          </div>
+
+          {error && (
+            <div className="mb-6 p-4 bg-red-50 border border-red-200 rounded-xl flex items-center gap-3">
+              <AlertCircle className="w-5 h-5 text-red-600 flex-shrink-0" />
+              <p className="text-red-700 text-sm">{error}</p>
+            </div>
+          )}
+
+          <div className="bg-white rounded-2xl shadow-xl border border-slate-200 p-8">
+            {done ? (
+              <div className="text-center">
+                <CheckCircle className="w-12 h-12 text-green-600 mx-auto mb-4" />
+                <p className="text-slate-600 mb-6">
+                  Password aggiornata. Per sicurezza tutte le sessioni sono state chiuse: accedi con la nuova password.
+                </p>
+                <Link to="/login" className="text-indigo-600 hover:text-indigo-700 font-semibold">
+                  Vai all'accesso
+                </Link>
+              </div>
+            ) : (
+              <form onSubmit={handleSubmit} className="space-y-5">
+                <div>
+                  <label className="block text-sm font-medium text-slate-700 mb-2">
+                    Nuova password
+                  </label>
+                  <div className="relative">
+                    <Lock className="absolute left-4 top-1/2 -translate-y-1/2 w-5 h-5 text-slate-400" />
+                    <input
+                      type="password"
+                      value={password}
+                      onChange={(e) => setPassword(e.target.value)}
+                      placeholder="••••••••"
+                      className="w-full pl-12 pr-4 py-3 bg-slate-50 border border-slate-200 rounded-xl focus:outline-none focus:ring-2 focus:ring-indigo-500 focus:border-transparent transition-all"
+                      required
+                      minLength={8}
+                    />
+                  </div>
+                </div>
+
+                <div>
+                  <label className="block text-sm font-medium text-slate-700 mb-2">
+                    Conferma password
+                  </label>
+                  <div className="relative">
+                    <Lock className="absolute left-4 top-1/2 -translate-y-1/2 w-5 h-5 text-slate-400" />
+                    <input
+                      type="password"
+                      value={confirmPassword}
+                      onChange={(e) => setConfirmPassword(e.target.value)}
+                      placeholder="••••••••"
+                      className="w-full pl-12 pr-4 py-3 bg-slate-50 border border-slate-200 rounded-xl focus:outline-none focus:ring-2 focus:ring-indigo-500 focus:border-transparent transition-all"
+                      required
+                      minLength={8}
+                    />
+                  </div>
+                </div>
+
+                <button
+                  type="submit"
+                  disabled={isLoading || !token}
+                  className="w-full py-3 bg-gradient-to-r from-indigo-600 to-purple-600 text-white font-semibold rounded-xl hover:shadow-lg hover:shadow-indigo-500/25 transition-all duration-300 flex items-center justify-center gap-2 disabled:opacity-50 disabled:cursor-not-allowed"
+                >
+                  {isLoading ? (
+                    <span className="w-5 h-5 border-2 border-white/30 border-t-white rounded-full animate-spin" />
+                  ) : (
+                    <>
+                      Salva la password
+                      <ArrowRight className="w-4 h-4" />
+                    </>
+                  )}
+                </button>
+              </form>
+            )}
+
+            {!done && (
+              <div className="mt-6 pt-6 border-t border-slate-200 text-center">
+                <Link to="/password-dimenticata" className="text-indigo-600 hover:text-indigo-700 font-semibold">
+                  Richiedi un nuovo link
+                </Link>
+              </div>
+            )}
+          </div>
+        </div>
+      </main>
+    </Layout>
+  );
+}
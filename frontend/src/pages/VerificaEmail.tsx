@@ -0,0 +1,58 @@
+import { useEffect, useState } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+import { CheckCircle, AlertCircle } from "lucide-react";
+import Layout from "../components/Layout";
+import { api, ApiError } from "../api";
+
+export default function VerificaEmail() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token") ?? "";
+  const [status, setStatus] = useState<"loading" | "ok" | "error">(token ? "loading" : "error");
+  const [error, setError] = useState(token ? "" : "Link di verifica non valido");
+
+  useEffect(() => {
+    if (!token) return;
+    api
+      .verifyEmail(token)
+      .then(() => setStatus("ok"))
+      .catch((err) => {
+        setStatus("error");
+        if (err instanceof ApiError && err.code === "verification_token_expired") {
+          setError("Il link è scaduto: accedi e richiedi una nuova email di verifica dal tuo profilo");
+        } else {
+          setError("Link di verifica non valido");
+        }
+      });
+  }, [token]);
+
+  return (
+    <Layout>
+      <main className="pt-32 pb-20 px-6">
+        <div className="max-w-md mx-auto bg-white rounded-2xl shadow-xl border border-slate-200 p-8 text-center">
+          {status === "loading" && (
+            <span className="inline-block w-8 h-8 border-2 border-indigo-200 border-t-indigo-600 rounded-full animate-spin" />
+          )}
+
+          {status === "ok" && (
+            <>
+              <CheckCircle className="w-12 h-12 text-green-600 mx-auto mb-4" />
+              <h1 className="text-2xl font-bold text-slate-900 mb-3">Email verificata!</h1>
+              <p className="text-slate-600 mb-6">Ora puoi creare conferenze e registrarti agli eventi.</p>
+              <Link to="/" className="text-indigo-600 hover:text-indigo-700 font-semibold">
+                Vai alle conferenze
+              </Link>
+            </>
+          )}
+
+          {status === "error" && (
+            <>
+              <AlertCircle className="w-12 h-12 text-red-600 mx-auto mb-4" />
+              <h1 className="text-2xl font-bold text-slate-900 mb-3">Verifica non riuscita</h1>
+              <p className="text-slate-600">{error}</p>
+            </>
+          )}
+        </div>
+      </main>
+    </Layout>
+  );
+}